@@ -7,8 +7,10 @@ package ast
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/MasaruFukazawa/monkey-lang/src/token"
 	"strings"
+	"sync/atomic"
 )
 
 // 抽象構文木のノードのインターフェース
@@ -16,6 +18,9 @@ type Node interface {
 	// Nodeを継承する構造体は、TokenLiteral()メソッドを実装しなければならない
 	TokenLiteral() string
 
+	// ソースコード上の開始位置を返す
+	Pos() token.Position
+
 	// デバック用に抽象構文木を文字列にして返す
 	// Nodeを継承する構造体は、String()メソッドを実装しなければならない
 	String() string
@@ -39,10 +44,154 @@ type Expression interface {
 	expressionNode()
 }
 
+// match式の「パターン」のインターフェース
+type Pattern interface {
+	// Nodeを継承する構造体は、TokenLiteral()メソッドを実装しなければならない
+	Node
+
+	// Patternを継承する構造体は、patternNode()メソッドを実装しなければならない
+	patternNode()
+}
+
+// throw文を表すノード
+// .. Statementインターフェースを満たす
+type ThrowStatement struct {
+	Token token.Token // 'throw' トークン
+	Value Expression  // 送出する値の式
+}
+
+func (ts *ThrowStatement) statementNode()       {}
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) Pos() token.Position  { return ts.Token.Position }
+func (ts *ThrowStatement) String() string {
+	return ts.TokenLiteral() + " " + ts.Value.String() + ";"
+}
+
+// defer文を表すノード
+// .. 関数を抜けるときに呼び出しを実行する
+// .. Statementインターフェースを満たす
+type DeferStatement struct {
+	Token token.Token     // 'defer' トークン
+	Call  *CallExpression // 関数を抜けるときに実行する呼び出し
+}
+
+func (ds *DeferStatement) statementNode()       {}
+func (ds *DeferStatement) TokenLiteral() string { return ds.Token.Literal }
+func (ds *DeferStatement) Pos() token.Position  { return ds.Token.Position }
+func (ds *DeferStatement) String() string {
+	return ds.TokenLiteral() + " " + ds.Call.String() + ";"
+}
+
+// 構造体の定義を表すノード
+// .. struct Point { x, y fn norm() { ... } }
+// .. Statementインターフェースを満たす
+type StructStatement struct {
+	Token   token.Token     // 'struct' トークン
+	Name    *Identifier     // 構造体の名前
+	Fields  []*Identifier   // フィールド名 ( 定義した順 )
+	Methods []*StructMethod // メソッド ( 定義した順 )
+}
+
+// 構造体のメソッドの定義
+// .. メソッドの本体では、self でレシーバ ( インスタンス ) を参照できる
+type StructMethod struct {
+	Name     *Identifier      // メソッド名
+	Function *FunctionLiteral // メソッドの引数と本体
+}
+
+func (ss *StructStatement) statementNode()       {}
+func (ss *StructStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *StructStatement) Pos() token.Position  { return ss.Token.Position }
+func (ss *StructStatement) String() string {
+
+	var out bytes.Buffer
+
+	fields := []string{}
+
+	for _, f := range ss.Fields {
+		fields = append(fields, f.String())
+	}
+
+	if len(ss.Fields) == 0 && len(ss.Methods) == 0 {
+		return "struct " + ss.Name.String() + " {}"
+	}
+
+	out.WriteString("struct " + ss.Name.String() + " { ")
+	out.WriteString(strings.Join(fields, ", "))
+
+	for _, m := range ss.Methods {
+
+		params := []string{}
+
+		for _, param := range m.Function.Parameters {
+			params = append(params, declarationString(param))
+		}
+
+		out.WriteString("; fn " + m.Name.String())
+		out.WriteString("(" + strings.Join(params, ", ") + ") ")
+
+		if m.Function.ReturnType != nil {
+			out.WriteString("-> " + m.Function.ReturnType.String() + " ")
+		}
+
+		out.WriteString(m.Function.Body.String())
+	}
+
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// 列挙型の定義を表すノード
+// .. enum Status { Pending, Done(result), Failed(reason) }
+// .. Statementインターフェースを満たす
+type EnumStatement struct {
+	Token    token.Token    // 'enum' トークン
+	Name     *Identifier    // 列挙型の名前
+	Variants []*EnumVariant // バリアント ( 定義した順 )
+}
+
+// 列挙型のバリアントの定義
+type EnumVariant struct {
+	Name   *Identifier   // バリアント名
+	Fields []*Identifier // ペイロードのフィールド名 : ペイロードを持たない場合は空
+}
+
+func (es *EnumStatement) statementNode()       {}
+func (es *EnumStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *EnumStatement) Pos() token.Position  { return es.Token.Position }
+func (es *EnumStatement) String() string {
+
+	if len(es.Variants) == 0 {
+		return "enum " + es.Name.String() + " {}"
+	}
+
+	variants := []string{}
+
+	for _, v := range es.Variants {
+
+		if len(v.Fields) == 0 {
+			variants = append(variants, v.Name.String())
+			continue
+		}
+
+		fields := []string{}
+
+		for _, f := range v.Fields {
+			fields = append(fields, f.String())
+		}
+
+		variants = append(variants, v.Name.String()+"("+strings.Join(fields, ", ")+")")
+	}
+
+	return "enum " + es.Name.String() + " { " + strings.Join(variants, ", ") + " }"
+}
+
 // LET文を表すノード
+// .. const文も同じノードで表す
 // .. Statementインターフェースを満たす
 type LetStatement struct {
-	Token token.Token // token.LET トークン
+	Token token.Token // token.LET または token.CONST トークン
 	Name  *Identifier // 変数名
 	Value Expression  // 変数名にバインドする式
 }
@@ -66,6 +215,25 @@ func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
 
+/**
+ * 名前: LetStatement.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Position
+}
+
+/**
+ * 名前: LetStatement.IsConst
+ * 概要:
+ *	const文であればtrueを返す
+ */
+func (ls *LetStatement) IsConst() bool {
+	return ls.Token.Type == token.CONST
+}
+
 /**
  * 名前: LetStatement.String
  * 概要:
@@ -78,7 +246,7 @@ func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	out.WriteString(declarationString(ls.Name))
 	out.WriteString(" = ")
 
 	// Valueがnilでない場合
@@ -115,6 +283,16 @@ func (rs *ReturnStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
 
+/**
+ * 名前: ReturnStatement.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (rs *ReturnStatement) Pos() token.Position {
+	return rs.Token.Position
+}
+
 /**
  * 名前: ReturnStatement.String
  * 概要:
@@ -165,6 +343,16 @@ func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
 
+/**
+ * 名前: ExpressionStatement.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (es *ExpressionStatement) Pos() token.Position {
+	return es.Token.Position
+}
+
 /**
  * 名前: ExpressionStatement.String
  * 概要:
@@ -183,8 +371,64 @@ func (es *ExpressionStatement) String() string {
 
 // 識別子(変数名・関数名)を表すノード
 type Identifier struct {
-	Token token.Token // token.IDENT トークン
-	Value string      // 変数名
+	Token token.Token     // token.IDENT トークン
+	Value string          // 変数名
+	Type  *TypeAnnotation // 型注釈 : let文の変数名と関数のパラメータにだけ書ける。省略した場合は nil
+}
+
+/**
+ * 名前: declarationString
+ * 概要:
+ *	宣言する名前を、型注釈があれば "x: int" の形式で返す
+ */
+func declarationString(i *Identifier) string {
+
+	if i.Type == nil {
+		return i.Value
+	}
+
+	return i.Value + ": " + i.Type.String()
+}
+
+/**
+ *
+ * 型注釈を表すノード
+ *  int, array[int], hash[string, int], fn(int, int) -> int, Point など
+ *
+ */
+type TypeAnnotation struct {
+	Token  token.Token       // 型名のトークン。関数型の場合は 'fn' トークン
+	Name   string            // 型名。関数型の場合は "fn"
+	Params []*TypeAnnotation // array[int] の int など、[ ] の中の型。関数型の場合はパラメータの型
+	Return *TypeAnnotation   // 関数型の戻り値の型 : 省略した場合は nil
+}
+
+func (ta *TypeAnnotation) TokenLiteral() string { return ta.Token.Literal }
+func (ta *TypeAnnotation) Pos() token.Position  { return ta.Token.Position }
+func (ta *TypeAnnotation) String() string {
+
+	params := []string{}
+
+	for _, p := range ta.Params {
+		params = append(params, p.String())
+	}
+
+	if ta.Name == "fn" {
+
+		out := "fn(" + strings.Join(params, ", ") + ")"
+
+		if ta.Return != nil {
+			out += " -> " + ta.Return.String()
+		}
+
+		return out
+	}
+
+	if len(params) == 0 {
+		return ta.Name
+	}
+
+	return ta.Name + "[" + strings.Join(params, ", ") + "]"
 }
 
 /**
@@ -205,6 +449,16 @@ func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+/**
+ * 名前: Identifier.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Position
+}
+
 /**
  * 名前: Identifier.String
  * 概要:
@@ -239,6 +493,16 @@ func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
 
+/**
+ * 名前: IntegerLiteral.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (il *IntegerLiteral) Pos() token.Position {
+	return il.Token.Position
+}
+
 /**
  * 名前: IntegerLiteral.String
  * 概要:
@@ -274,6 +538,16 @@ func (pe *PrefixExpression) TokenLiteral() string {
 	return pe.Token.Literal
 }
 
+/**
+ * 名前: PrefixExpression.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Position
+}
+
 /**
  * 名前: PrefixExpression.String
  * 概要:
@@ -318,6 +592,16 @@ func (oe *InfixExpression) TokenLiteral() string {
 	return oe.Token.Literal
 }
 
+/**
+ * 名前: InfixExpression.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (oe *InfixExpression) Pos() token.Position {
+	return oe.Token.Position
+}
+
 /**
  * 名前: InfixExpression.String
  * 概要:
@@ -337,6 +621,69 @@ func (oe *InfixExpression) String() string {
 	return out.String()
 }
 
+/**
+ * 名前: 代入式を表すノード
+ * 説明:
+ *  既に宣言された変数に値を代入する ( x = 5 )
+ *  代入式の値は代入した値となる
+ */
+type AssignExpression struct {
+	Token token.Token // '=' トークン
+	Name  *Identifier // 代入先の変数名
+	Value Expression  // 代入する式
+}
+
+func (ae *AssignExpression) expressionNode() {}
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+func (ae *AssignExpression) Pos() token.Position {
+	return ae.Token.Position
+}
+func (ae *AssignExpression) String() string {
+
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ae.Name.String())
+	out.WriteString(" = ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+/**
+ * 名前: パイプライン式を表すノード
+ * 説明:
+ *  左辺の値を右辺の関数呼び出しの第1引数として渡す ( xs |> map(f) )
+ */
+type PipeExpression struct {
+	Token token.Token // '|>' トークン
+	Left  Expression  // 渡す値の式
+	Right Expression  // 呼び出す関数の式
+}
+
+func (pe *PipeExpression) expressionNode() {}
+func (pe *PipeExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+func (pe *PipeExpression) Pos() token.Position {
+	return pe.Token.Position
+}
+func (pe *PipeExpression) String() string {
+
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(" |> ")
+	out.WriteString(pe.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 /**
  *
  * 真偽値を表すノード
@@ -365,6 +712,16 @@ func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
 
+/**
+ * 名前: Boolean.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Position
+}
+
 /**
  * 名前: Boolean.String
  * 概要:
@@ -405,6 +762,16 @@ func (ie *IfExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+/**
+ * 名前: IfExpression.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (ie *IfExpression) Pos() token.Position {
+	return ie.Token.Position
+}
+
 /**
  * 名前: IfExpression.String
  * 概要:
@@ -428,6 +795,48 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 
+/**
+ *
+ * try式を表すノード
+ *  try { ... } catch (e) { ... } finally { ... }
+ *  catch と finally はどちらか一方を省略できる
+ *
+ */
+type TryExpression struct {
+	Token     token.Token     // 'try' トークン
+	Block     *BlockStatement // 例外を捕捉する文
+	Parameter *Identifier     // 捕捉した例外を束縛する変数名 : 省略時は nil
+	Catch     *BlockStatement // 例外を捕捉したときの文 : 省略時は nil
+	Finally   *BlockStatement // 例外の有無によらず最後に実行する文 : 省略時は nil
+}
+
+func (te *TryExpression) expressionNode()      {}
+func (te *TryExpression) TokenLiteral() string { return te.Token.Literal }
+func (te *TryExpression) Pos() token.Position  { return te.Token.Position }
+func (te *TryExpression) String() string {
+
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(te.Block.String())
+
+	if te.Catch != nil {
+		out.WriteString(" catch")
+
+		if te.Parameter != nil {
+			out.WriteString(" (" + te.Parameter.String() + ")")
+		}
+
+		out.WriteString(" " + te.Catch.String())
+	}
+
+	if te.Finally != nil {
+		out.WriteString(" finally " + te.Finally.String())
+	}
+
+	return out.String()
+}
+
 /**
  * 名前: BlockStatement
  * 概要:
@@ -457,6 +866,16 @@ func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
 
+/**
+ * 名前: BlockStatement.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Position
+}
+
 /**
  * 名前: BlockStatement.String
  * 概要:
@@ -480,8 +899,9 @@ func (bs *BlockStatement) String() string {
  *
  */
 type FunctionLiteral struct {
-	Token      token.Token     // 'fn' トークン
+	Token      token.Token     // 'fn' トークン。アロー関数の場合は '=>' トークン
 	Parameters []*Identifier   // パラメータリスト
+	ReturnType *TypeAnnotation // 戻り値の型注釈 : 省略した場合は nil
 	Body       *BlockStatement // 関数の本体
 }
 
@@ -503,6 +923,16 @@ func (fl *FunctionLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
 
+/**
+ * 名前: FunctionLiteral.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Position
+}
+
 /**
  * 名前: FunctionLiteral.String
  * 概要:
@@ -516,13 +946,27 @@ func (fl *FunctionLiteral) String() string {
 	params := []string{}
 
 	for _, p := range fl.Parameters {
-		params = append(params, p.String())
+		params = append(params, declarationString(p))
+	}
+
+	// アロー関数の場合は (params) => body とする
+	if fl.Token.Type == token.ARROW {
+		out.WriteString("(")
+		out.WriteString(strings.Join(params, ", "))
+		out.WriteString(") => ")
+		out.WriteString(fl.Body.String())
+		return out.String()
 	}
 
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
+
+	if fl.ReturnType != nil {
+		out.WriteString("-> " + fl.ReturnType.String() + " ")
+	}
+
 	out.WriteString(fl.Body.String())
 
 	return out.String()
@@ -557,6 +1001,16 @@ func (ce *CallExpression) TokenLiteral() string {
 	return ce.Token.Literal
 }
 
+/**
+ * 名前: CallExpression.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (ce *CallExpression) Pos() token.Position {
+	return ce.Token.Position
+}
+
 /**
  * 名前: CallExpression.String
  * 概要:
@@ -583,11 +1037,13 @@ func (ce *CallExpression) String() string {
 /**
  * 名前: 文字列リテラルを表すノード
  * 説明:
- *
+ *  Interned には、評価器がこのリテラルから生成した文字列オブジェクトを入れて使い回す
+ *  ノードと同じ期間だけ保持され、複数のゴルーチンから評価しても安全に読み書きできる
  */
 type StringLiteral struct {
-	Token token.Token // 文字列リテラルのトークン
-	Value string      // 文字列リテラルの値
+	Token    token.Token  // 文字列リテラルのトークン
+	Value    string       // 文字列リテラルの値
+	Interned atomic.Value // 評価した結果 ( ast は object に依存できないので、型を限定しない )
 }
 
 /**
@@ -606,6 +1062,16 @@ func (sl *StringLiteral) TokenLiteral() string {
 	return sl.Token.Literal
 }
 
+/**
+ * 名前: StringLiteral.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Position
+}
+
 /**
  * 名前: StringLiteral.String
  * 概要:
@@ -615,29 +1081,137 @@ func (sl *StringLiteral) String() string {
 	return sl.Token.Literal
 }
 
+/**
+ * 名前: バイト列リテラルを表すノード
+ * 説明:
+ *  b"..." で記述したバイト列を表す
+ *  トークンリテラルはエスケープシーケンスを含む記述のままで、Value は解釈した後のバイト列とする
+ */
+type BytesLiteral struct {
+	Token token.Token // バイト列リテラルのトークン
+	Value []byte      // バイト列リテラルの値
+}
+
+func (bl *BytesLiteral) expressionNode() {}
+
+func (bl *BytesLiteral) TokenLiteral() string {
+	return bl.Token.Literal
+}
+
+func (bl *BytesLiteral) Pos() token.Position {
+	return bl.Token.Position
+}
+
+func (bl *BytesLiteral) String() string {
+	return "b\"" + bl.Token.Literal + "\""
+}
+
 /**
  * 名前: 配列リテラルを表すノード
  * 説明:
  *  配列リテラルの要素を保持する
  */
-type ArrayLiteral struct {
-	Token    token.Token // '[' トークン
+/**
+ * 名前: タプルリテラルを表すノード
+ * 説明:
+ *  要素を変更できない値の組を表す ( (1, 2) )
+ *  要素が1つの場合は末尾にカンマを付けて、括弧でくくった式と区別する ( (1,) )
+ */
+type TupleLiteral struct {
+	Token    token.Token // '(' トークン
 	Elements []Expression
 }
 
-func (al *ArrayLiteral) expressionNode() {}
+func (tl *TupleLiteral) expressionNode() {}
 
-func (al *ArrayLiteral) TokenLiteral() string {
-	return al.Token.Literal
+func (tl *TupleLiteral) TokenLiteral() string {
+	return tl.Token.Literal
 }
 
-func (al *ArrayLiteral) String() string {
+func (tl *TupleLiteral) Pos() token.Position {
+	return tl.Token.Position
+}
 
-	var out bytes.Buffer
+func (tl *TupleLiteral) String() string {
 
 	elements := []string{}
 
-	for _, el := range al.Elements {
+	for _, el := range tl.Elements {
+		elements = append(elements, el.String())
+	}
+
+	return tupleString(elements)
+}
+
+// タプルの要素を括弧でくくる。要素が1つの場合は末尾にカンマを付ける
+func tupleString(elements []string) string {
+
+	if len(elements) == 1 {
+		return "(" + elements[0] + ",)"
+	}
+
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+/**
+ * 名前: セットリテラルを表すノード
+ * 説明:
+ *  重複しない値の集まりを表す ( #{1, 2, 3} )
+ */
+type SetLiteral struct {
+	Token    token.Token // '#{' トークン
+	Elements []Expression
+}
+
+func (sl *SetLiteral) expressionNode() {}
+
+func (sl *SetLiteral) TokenLiteral() string {
+	return sl.Token.Literal
+}
+
+func (sl *SetLiteral) Pos() token.Position {
+	return sl.Token.Position
+}
+
+func (sl *SetLiteral) String() string {
+
+	elements := []string{}
+
+	for _, el := range sl.Elements {
+		elements = append(elements, el.String())
+	}
+
+	return "#{" + strings.Join(elements, ", ") + "}"
+}
+
+type ArrayLiteral struct {
+	Token    token.Token // '[' トークン
+	Elements []Expression
+}
+
+func (al *ArrayLiteral) expressionNode() {}
+
+func (al *ArrayLiteral) TokenLiteral() string {
+	return al.Token.Literal
+}
+
+/**
+ * 名前: ArrayLiteral.Pos
+ * 概要:
+ *	ソースコード上の開始位置を返す
+ *  .. Nodeインターフェースを満たす
+ */
+func (al *ArrayLiteral) Pos() token.Position {
+	return al.Token.Position
+}
+
+func (al *ArrayLiteral) String() string {
+
+	var out bytes.Buffer
+
+	elements := []string{}
+
+	for _, el := range al.Elements {
 		elements = append(elements, el.String())
 	}
 
@@ -654,18 +1228,23 @@ func (al *ArrayLiteral) String() string {
  *  配列の要素を取得するための添字演算式
  */
 type IndexExpression struct {
-	Token token.Token // The [ token
-	Left  Expression
-	Index Expression
+	Token    token.Token // The [ token
+	Left     Expression
+	Index    Expression
+	Optional bool // ?[ の場合はtrue。左辺がnullのときはnullを返す
 }
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position  { return ie.Token.Position }
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
@@ -673,6 +1252,93 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+/**
+ * 名前: スライス式を表すノード
+ * 説明:
+ *  配列や文字列の一部を取り出す ( arr[start:end:step] )
+ *  省略した境界はnilとなる
+ */
+type SliceExpression struct {
+	Token    token.Token // '[' または '?[' トークン
+	Left     Expression  // スライスする対象の式
+	Start    Expression  // 開始位置。省略した場合はnil
+	End      Expression  // 終了位置 ( この位置は含まない ) 。省略した場合はnil
+	Step     Expression  // 増分。省略した場合はnil
+	Optional bool        // ?[ の場合はtrue。左辺がnullのときはnullを返す
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) Pos() token.Position  { return se.Token.Position }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	if se.Optional {
+		out.WriteString("?")
+	}
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	if se.Step != nil {
+		out.WriteString(":")
+		out.WriteString(se.Step.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
+/**
+ * 名前: メンバアクセス式を表すノード
+ * 説明:
+ *  左辺の値のメンバを名前で取得する ( config.db, "abc".upper )
+ */
+type MemberExpression struct {
+	Token    token.Token // '.' または '?.' トークン
+	Object   Expression  // 左辺の式
+	Property *Identifier // メンバ名
+	Optional bool        // ?. の場合はtrue。左辺がnullのときはnullを返す
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) Pos() token.Position  { return me.Token.Position }
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(me.Object.String())
+	if me.Optional {
+		out.WriteString("?")
+	}
+	out.WriteString(".")
+	out.WriteString(me.Property.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+/**
+ * 名前: nullリテラルを表すノード
+ * 説明:
+ *  値が存在しないことを表す
+ */
+type NullLiteral struct {
+	Token token.Token // 'null' トークン
+}
+
+func (nl *NullLiteral) expressionNode()      {}
+func (nl *NullLiteral) TokenLiteral() string { return nl.Token.Literal }
+func (nl *NullLiteral) Pos() token.Position  { return nl.Token.Position }
+func (nl *NullLiteral) String() string       { return nl.Token.Literal }
+
 /**
  * 名前: ハッシュリテラルを表すノード
  * 説明:
@@ -681,20 +1347,24 @@ func (ie *IndexExpression) String() string {
 type HashLiteral struct {
 	Token token.Token               // '{' トークン
 	Pairs map[Expression]Expression // ハッシュリテラルの要素
+	Keys  []Expression              // キー ( 記述した順 )
 }
 
 func (hl *HashLiteral) expressionNode() {}
 func (hl *HashLiteral) TokenLiteral() string {
 	return hl.Token.Literal
 }
+func (hl *HashLiteral) Pos() token.Position {
+	return hl.Token.Position
+}
 func (hl *HashLiteral) String() string {
 
 	var out bytes.Buffer
 
 	pairs := []string{}
 
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 
 	out.WriteString("{")
@@ -704,6 +1374,439 @@ func (hl *HashLiteral) String() string {
 	return out.String()
 }
 
+/**
+ * 名前: match式を表すノード
+ * 説明:
+ *  対象の値を上から順にパターンと照合し、最初に一致した腕の式を評価する
+ */
+type MatchExpression struct {
+	Token   token.Token // 'match' トークン
+	Subject Expression  // 照合対象の式
+	Arms    []*MatchArm // match式の腕
+}
+
+func (me *MatchExpression) expressionNode() {}
+func (me *MatchExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+func (me *MatchExpression) Pos() token.Position {
+	return me.Token.Position
+}
+func (me *MatchExpression) String() string {
+
+	var out bytes.Buffer
+
+	arms := []string{}
+
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	out.WriteString("match (")
+	out.WriteString(me.Subject.String())
+	out.WriteString(") {")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+/**
+ * 名前: match式の腕を表すノード
+ * 説明:
+ *  パターン、省略可能なガード条件、一致したときに評価する式を保持する
+ */
+type MatchArm struct {
+	Token   token.Token // パターンの最初のトークン
+	Pattern Pattern     // パターン
+	Guard   Expression  // ガード条件 ( if cond )。無い場合はnil
+	Body    Expression  // 一致したときに評価する式
+}
+
+func (ma *MatchArm) TokenLiteral() string {
+	return ma.Token.Literal
+}
+func (ma *MatchArm) Pos() token.Position {
+	return ma.Token.Position
+}
+func (ma *MatchArm) String() string {
+
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+
+	return out.String()
+}
+
+/**
+ * 名前: ワイルドカードパターンを表すノード
+ * 説明:
+ *  _ は任意の値に一致し、変数を束縛しない
+ */
+type WildcardPattern struct {
+	Token token.Token // '_' トークン
+}
+
+func (wp *WildcardPattern) patternNode() {}
+func (wp *WildcardPattern) TokenLiteral() string {
+	return wp.Token.Literal
+}
+func (wp *WildcardPattern) Pos() token.Position {
+	return wp.Token.Position
+}
+func (wp *WildcardPattern) String() string {
+	return "_"
+}
+
+/**
+ * 名前: リテラルパターンを表すノード
+ * 説明:
+ *  整数・文字列・真偽値のリテラルと等しい値に一致する
+ */
+type LiteralPattern struct {
+	Token token.Token // リテラルの最初のトークン
+	Value Expression  // リテラルの式
+}
+
+func (lp *LiteralPattern) patternNode() {}
+func (lp *LiteralPattern) TokenLiteral() string {
+	return lp.Token.Literal
+}
+func (lp *LiteralPattern) Pos() token.Position {
+	return lp.Token.Position
+}
+func (lp *LiteralPattern) String() string {
+	return lp.Value.String()
+}
+
+/**
+ * 名前: 束縛パターンを表すノード
+ * 説明:
+ *  任意の値に一致し、その値を識別子に束縛する
+ */
+type BindingPattern struct {
+	Token token.Token // token.IDENT トークン
+	Name  *Identifier // 束縛する変数名
+}
+
+func (bp *BindingPattern) patternNode() {}
+func (bp *BindingPattern) TokenLiteral() string {
+	return bp.Token.Literal
+}
+func (bp *BindingPattern) Pos() token.Position {
+	return bp.Token.Position
+}
+func (bp *BindingPattern) String() string {
+	return bp.Name.String()
+}
+
+/**
+ * 名前: 配列パターンを表すノード
+ * 説明:
+ *  要素ごとにパターンと照合する。...rest があれば残りの要素を配列として束縛する
+ */
+type ArrayPattern struct {
+	Token    token.Token // '[' トークン
+	Elements []Pattern   // 先頭から照合する要素のパターン
+	Rest     *Identifier // 残りの要素を束縛する変数名。無い場合はnil
+}
+
+func (ap *ArrayPattern) patternNode() {}
+func (ap *ArrayPattern) TokenLiteral() string {
+	return ap.Token.Literal
+}
+func (ap *ArrayPattern) Pos() token.Position {
+	return ap.Token.Position
+}
+func (ap *ArrayPattern) String() string {
+
+	var out bytes.Buffer
+
+	elements := []string{}
+
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+/**
+ * 名前: タプルパターンを表すノード
+ * 説明:
+ *  要素の数が同じタプルに一致し、各要素をパターンと照合する ( (x, y) )
+ */
+type TuplePattern struct {
+	Token    token.Token // '(' トークン
+	Elements []Pattern   // 先頭から照合する要素のパターン
+}
+
+func (tp *TuplePattern) patternNode() {}
+func (tp *TuplePattern) TokenLiteral() string {
+	return tp.Token.Literal
+}
+func (tp *TuplePattern) Pos() token.Position {
+	return tp.Token.Position
+}
+func (tp *TuplePattern) String() string {
+
+	elements := []string{}
+
+	for _, el := range tp.Elements {
+		elements = append(elements, el.String())
+	}
+
+	return tupleString(elements)
+}
+
+/**
+ * 名前: 列挙型のパターンを表すノード
+ * 説明:
+ *  Status.Done(r) のように、指定したバリアントの値に一致し、ペイロードをそれぞれのパターンと照合する
+ *  ( ) を省略した場合は、ペイロードによらずバリアントだけを照合する
+ */
+type EnumPattern struct {
+	Token   token.Token // 列挙型の名前のトークン
+	Enum    *Identifier // 列挙型の名前
+	Variant *Identifier // バリアント名
+	Payload []Pattern   // ペイロードのパターン。( ) を省略した場合はnil
+}
+
+func (ep *EnumPattern) patternNode() {}
+func (ep *EnumPattern) TokenLiteral() string {
+	return ep.Token.Literal
+}
+func (ep *EnumPattern) Pos() token.Position {
+	return ep.Token.Position
+}
+func (ep *EnumPattern) String() string {
+
+	var out bytes.Buffer
+
+	out.WriteString(ep.Enum.String() + "." + ep.Variant.String())
+
+	if ep.Payload != nil {
+
+		payload := []string{}
+
+		for _, p := range ep.Payload {
+			payload = append(payload, p.String())
+		}
+
+		out.WriteString("(" + strings.Join(payload, ", ") + ")")
+	}
+
+	return out.String()
+}
+
+/**
+ * 名前: ハッシュパターンを表すノード
+ * 説明:
+ *  指定したキーをすべて持ち、その値がそれぞれのパターンに一致するハッシュに一致する
+ */
+type HashPattern struct {
+	Token  token.Token  // '{' トークン
+	Keys   []Expression // キーのリテラル
+	Values []Pattern    // キーに対応する値のパターン
+}
+
+func (hp *HashPattern) patternNode() {}
+func (hp *HashPattern) TokenLiteral() string {
+	return hp.Token.Literal
+}
+func (hp *HashPattern) Pos() token.Position {
+	return hp.Token.Position
+}
+func (hp *HashPattern) String() string {
+
+	var out bytes.Buffer
+
+	pairs := []string{}
+
+	for i, key := range hp.Keys {
+		pairs = append(pairs, key.String()+": "+hp.Values[i].String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+/**
+ * 名前: 配列の内包表記を表すノード
+ * 説明:
+ *  [expr for x in xs if cond] のように、反復した要素から配列を組み立てる
+ */
+type ListComprehension struct {
+	Token   token.Token            // '[' トークン
+	Element Expression             // 要素の式
+	Clauses []*ComprehensionClause // for節の一覧 ( 先頭が最も外側の反復 )
+}
+
+func (lc *ListComprehension) expressionNode() {}
+func (lc *ListComprehension) TokenLiteral() string {
+	return lc.Token.Literal
+}
+func (lc *ListComprehension) Pos() token.Position {
+	return lc.Token.Position
+}
+func (lc *ListComprehension) String() string {
+
+	var out bytes.Buffer
+
+	out.WriteString("[")
+	out.WriteString(lc.Element.String())
+
+	for _, clause := range lc.Clauses {
+		out.WriteString(" ")
+		out.WriteString(clause.String())
+	}
+
+	out.WriteString("]")
+
+	return out.String()
+}
+
+/**
+ * 名前: ハッシュの内包表記を表すノード
+ * 説明:
+ *  {k: v for k, v in h} のように、反復した要素からハッシュを組み立てる
+ */
+type HashComprehension struct {
+	Token   token.Token            // '{' トークン
+	Key     Expression             // キーの式
+	Value   Expression             // 値の式
+	Clauses []*ComprehensionClause // for節の一覧 ( 先頭が最も外側の反復 )
+}
+
+func (hc *HashComprehension) expressionNode() {}
+func (hc *HashComprehension) TokenLiteral() string {
+	return hc.Token.Literal
+}
+func (hc *HashComprehension) Pos() token.Position {
+	return hc.Token.Position
+}
+func (hc *HashComprehension) String() string {
+
+	var out bytes.Buffer
+
+	out.WriteString("{")
+	out.WriteString(hc.Key.String())
+	out.WriteString(":")
+	out.WriteString(hc.Value.String())
+
+	for _, clause := range hc.Clauses {
+		out.WriteString(" ")
+		out.WriteString(clause.String())
+	}
+
+	out.WriteString("}")
+
+	return out.String()
+}
+
+/**
+ * 名前: 内包表記のfor節を表すノード
+ * 説明:
+ *  for x in xs if cond のように、反復する変数、反復対象、絞り込みの条件を保持する
+ */
+type ComprehensionClause struct {
+	Token      token.Token   // 'for' トークン
+	Variables  []*Identifier // 反復する変数 ( 1つまたは2つ )
+	Iterable   Expression    // 反復対象の式
+	Conditions []Expression  // 絞り込みの条件 ( if cond )
+}
+
+func (cc *ComprehensionClause) TokenLiteral() string {
+	return cc.Token.Literal
+}
+func (cc *ComprehensionClause) Pos() token.Position {
+	return cc.Token.Position
+}
+func (cc *ComprehensionClause) String() string {
+
+	var out bytes.Buffer
+
+	vars := []string{}
+
+	for _, v := range cc.Variables {
+		vars = append(vars, v.String())
+	}
+
+	out.WriteString("for ")
+	out.WriteString(strings.Join(vars, ", "))
+	out.WriteString(" in ")
+	out.WriteString(cc.Iterable.String())
+
+	for _, cond := range cc.Conditions {
+		out.WriteString(" if ")
+		out.WriteString(cond.String())
+	}
+
+	return out.String()
+}
+
+/**
+ * 名前: import文を表すノード
+ * 説明:
+ *  import "path/to/lib.monkey" as lib のように、モジュールを読み込んで名前に束縛する
+ */
+type ImportStatement struct {
+	Token token.Token // 'import' トークン
+	Path  string      // モジュールのファイルのパス
+	Name  *Identifier // モジュールを束縛する名前
+}
+
+func (is *ImportStatement) statementNode() {}
+func (is *ImportStatement) TokenLiteral() string {
+	return is.Token.Literal
+}
+func (is *ImportStatement) Pos() token.Position {
+	return is.Token.Position
+}
+func (is *ImportStatement) String() string {
+	return fmt.Sprintf("import %q as %s;", is.Path, is.Name.String())
+}
+
+/**
+ * 名前: export文を表すノード
+ * 説明:
+ *  モジュールの外から参照できる宣言 ( export let x = 1; )
+ */
+type ExportStatement struct {
+	Token     token.Token // 'export' トークン
+	Statement Statement   // 公開する宣言
+}
+
+func (es *ExportStatement) statementNode() {}
+func (es *ExportStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+func (es *ExportStatement) Pos() token.Position {
+	return es.Token.Position
+}
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Statement.String()
+}
+
 // プログラム全体を表すノード
 // .. Nodeインターフェースを満たす
 type Program struct {
@@ -726,6 +1829,19 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+/**
+ * 名前: Pos
+ * 概要: プログラム全体の文の配列の先頭の位置を返す
+ */
+func (p *Program) Pos() token.Position {
+
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+
+	return token.Position{}
+}
+
 /**
  * 名前: String
  * 概要: デバック用に抽象構文木を文字列にして返す
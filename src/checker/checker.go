@@ -0,0 +1,537 @@
+/**
+ * パッケージ名: checker
+ * ファイル名: checker.go
+ * 概要: 型注釈をもとに、評価する前にプログラムの型の誤りを検査する
+ *  型注釈の無い式は可能な範囲で型を推論し、推論できない式は any として検査しない
+ */
+package checker
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/MasaruFukazawa/monkey-lang/src/ast"
+	"github.com/MasaruFukazawa/monkey-lang/src/token"
+)
+
+// 検査で見つかった誤りを表す構造体
+type Error struct {
+	Position token.Position // 誤りのある位置
+	Message  string         // エラーメッセージ
+}
+
+// "行:列: メッセージ" の形式の文字列を返す
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.Message)
+}
+
+// 組み込み関数の型
+// .. 引数を検査しない関数は Params を nil とする
+var builtins = map[string]*Type{
+	"len":   funcOf(nil, IntType),
+	"puts":  funcOf(nil, NullType),
+	"first": funcOf(nil, AnyType),
+	"last":  funcOf(nil, AnyType),
+	"rest":  funcOf(nil, AnyType),
+	"push":  funcOf(nil, AnyType),
+	"set":   funcOf(nil, setOf(AnyType)),
+
+	"bytes_len": funcOf([]*Type{StringType}, IntType),
+	"graphemes": funcOf([]*Type{StringType}, arrayOf(StringType)),
+
+	"bytes":      funcOf(nil, BytesType),
+	"from_hex":   funcOf([]*Type{StringType}, BytesType),
+	"from_text":  funcOf([]*Type{StringType, StringType}, BytesType),
+	"read_file":  funcOf([]*Type{StringType}, StringType),
+	"read_bytes": funcOf([]*Type{StringType}, BytesType),
+	"write_file": funcOf(nil, IntType),
+}
+
+// 名前に束縛した型
+type binding struct {
+	typ       *Type
+	annotated bool // 型注釈で宣言した名前であれば true。代入する値の型を検査する
+}
+
+// 名前と型の対応を保持するスコープ
+type scope struct {
+	names map[string]binding
+	outer *scope
+}
+
+// 検査中の関数の情報
+type frame struct {
+	ret      *Type // 戻り値の型注釈 : 省略した場合は nil
+	returned bool  // 本体に return文があれば true
+}
+
+// 構造体の定義
+type structInfo struct {
+	fields  map[string]bool
+	methods map[string]*Type
+}
+
+// 検査器を表す構造体
+type Checker struct {
+	errors  []*Error
+	scope   *scope
+	frames  []*frame
+	structs map[string]*structInfo    // 構造体名と定義
+	enums   map[string]map[string]int // 列挙型名と、バリアント名ごとのペイロードのフィールド数
+}
+
+/**
+ * 関数名: Check
+ * 処理: プログラムを検査し、見つかった誤りを位置の順に返す
+ * 引数: プログラム
+ * 戻値: 誤りのリスト : 誤りが無ければ空
+ */
+func Check(program *ast.Program) []*Error {
+
+	c := &Checker{
+		scope:   &scope{names: map[string]binding{}},
+		structs: map[string]*structInfo{},
+		enums:   map[string]map[string]int{},
+	}
+
+	// 型注釈では、後で定義する構造体・列挙型も参照できる
+	for _, stmt := range program.Statements {
+		c.declareType(stmt)
+	}
+
+	for _, stmt := range program.Statements {
+		c.statement(stmt)
+	}
+
+	sort.SliceStable(c.errors, func(i, j int) bool {
+		a, b := c.errors[i].Position, c.errors[j].Position
+		return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+	})
+
+	return c.errors
+}
+
+/**
+ * 関数名: Checker.errorf
+ * 処理: 誤りを記録する
+ * 引数: 位置, 書式, 値
+ * 戻値: なし
+ */
+func (c *Checker) errorf(pos token.Position, format string, a ...interface{}) {
+
+	err := &Error{Position: pos, Message: fmt.Sprintf(format, a...)}
+
+	// 同じ型注釈を何度か解決することがあるので、同じ誤りは1度だけ記録する
+	for _, e := range c.errors {
+		if *e == *err {
+			return
+		}
+	}
+
+	c.errors = append(c.errors, err)
+}
+
+/**
+ * 関数名: Checker.enterScope / Checker.leaveScope
+ * 処理: スコープに入る / スコープから出る
+ */
+func (c *Checker) enterScope() {
+	c.scope = &scope{names: map[string]binding{}, outer: c.scope}
+}
+
+func (c *Checker) leaveScope() {
+	c.scope = c.scope.outer
+}
+
+/**
+ * 関数名: Checker.declare
+ * 処理: 現在のスコープで名前に型を束縛する
+ * 引数: 名前, 型, 型注釈で宣言したかどうか
+ * 戻値: なし
+ */
+func (c *Checker) declare(name string, typ *Type, annotated bool) {
+	c.scope.names[name] = binding{typ: typ, annotated: annotated}
+}
+
+/**
+ * 関数名: Checker.lookup
+ * 処理: 名前に束縛した型を内側のスコープから探す
+ * 引数: 名前
+ * 戻値: 束縛, 見つかったかどうか
+ */
+func (c *Checker) lookup(name string) (binding, bool) {
+
+	for s := c.scope; s != nil; s = s.outer {
+		if b, ok := s.names[name]; ok {
+			return b, true
+		}
+	}
+
+	if typ, ok := builtins[name]; ok {
+		return binding{typ: typ}, true
+	}
+
+	return binding{}, false
+}
+
+/**
+ * 関数名: Checker.widen
+ * 処理: 名前を束縛したスコープで、名前の型を値の型とまとめた型に広げる
+ * 引数: 名前, 値の型
+ * 戻値: なし
+ */
+func (c *Checker) widen(name string, typ *Type) {
+
+	for s := c.scope; s != nil; s = s.outer {
+		if b, ok := s.names[name]; ok {
+			s.names[name] = binding{typ: join(b.typ, typ), annotated: b.annotated}
+			return
+		}
+	}
+}
+
+/**
+ * 関数名: Checker.declareType
+ * 処理: 構造体・列挙型の名前を、型注釈で使える型として登録する
+ * 引数: 文
+ * 戻値: なし
+ */
+func (c *Checker) declareType(stmt ast.Statement) {
+
+	switch stmt := stmt.(type) {
+	case *ast.ExportStatement:
+		c.declareType(stmt.Statement)
+	case *ast.StructStatement:
+		info := &structInfo{fields: map[string]bool{}, methods: map[string]*Type{}}
+
+		for _, f := range stmt.Fields {
+			info.fields[f.Value] = true
+		}
+
+		c.structs[stmt.Name.Value] = info
+	case *ast.EnumStatement:
+		variants := map[string]int{}
+
+		for _, v := range stmt.Variants {
+			variants[v.Name.Value] = len(v.Fields)
+		}
+
+		c.enums[stmt.Name.Value] = variants
+	}
+}
+
+/**
+ * 関数名: Checker.resolve
+ * 処理: 型注釈を型にする
+ * 引数: 型注釈
+ * 戻値: 型 : 型注釈が無い・誤っている場合は any
+ */
+func (c *Checker) resolve(ta *ast.TypeAnnotation) *Type {
+
+	if ta == nil {
+		return AnyType
+	}
+
+	params := []*Type{}
+
+	for _, p := range ta.Params {
+		params = append(params, c.resolve(p))
+	}
+
+	// 型引数の数を検査する
+	arity := func(want ...int) bool {
+		for _, n := range want {
+			if len(params) == n {
+				return true
+			}
+		}
+		c.errorf(ta.Pos(), "wrong number of type arguments for %s: got=%d", ta.Name, len(params))
+		return false
+	}
+
+	switch ta.Name {
+	case "fn":
+		return funcOf(params, c.resolve(ta.Return))
+	case "array":
+		if !arity(0, 1) || len(params) == 0 {
+			return arrayOf(AnyType)
+		}
+		return arrayOf(params[0])
+	case "tuple":
+		if len(params) == 0 {
+			return tupleOf(nil)
+		}
+		return tupleOf(params)
+	case "set":
+		if !arity(0, 1) || len(params) == 0 {
+			return setOf(AnyType)
+		}
+		return setOf(params[0])
+	case "hash":
+		if !arity(0, 2) || len(params) == 0 {
+			return hashOf(AnyType, AnyType)
+		}
+		return hashOf(params[0], params[1])
+	}
+
+	if !arity(0) {
+		return AnyType
+	}
+
+	if typ, ok := basicTypes[ta.Name]; ok {
+		return typ
+	}
+
+	_, isStruct := c.structs[ta.Name]
+	_, isEnum := c.enums[ta.Name]
+
+	if isStruct || isEnum {
+		return namedOf(ta.Name)
+	}
+
+	c.errorf(ta.Pos(), "unknown type: %s", ta.Name)
+
+	return AnyType
+}
+
+/**
+ * 関数名: Checker.signature
+ * 処理: 関数リテラルの型注釈から関数の型を求める
+ * 引数: 関数リテラル
+ * 戻値: 関数の型
+ */
+func (c *Checker) signature(fl *ast.FunctionLiteral) *Type {
+
+	params := []*Type{}
+
+	for _, p := range fl.Parameters {
+		params = append(params, c.resolve(p.Type))
+	}
+
+	return funcOf(params, c.resolve(fl.ReturnType))
+}
+
+/**
+ * 関数名: Checker.statement
+ * 処理: 文を検査する
+ * 引数: 文
+ * 戻値: 式文であれば式の型、return文・throw文は any、それ以外は null
+ */
+func (c *Checker) statement(stmt ast.Statement) *Type {
+
+	switch stmt := stmt.(type) {
+
+	case *ast.ExpressionStatement:
+		if stmt.Expression == nil {
+			return AnyType
+		}
+		return c.expression(stmt.Expression)
+
+	case *ast.LetStatement:
+		c.letStatement(stmt)
+
+	// return文・throw文の後の値は使われないので any とする
+	case *ast.ReturnStatement:
+		c.returnStatement(stmt)
+		return AnyType
+
+	case *ast.ThrowStatement:
+		c.expression(stmt.Value)
+		return AnyType
+
+	case *ast.DeferStatement:
+		c.expression(stmt.Call)
+
+	case *ast.ExportStatement:
+		c.statement(stmt.Statement)
+
+	case *ast.ImportStatement:
+		c.declare(stmt.Name.Value, AnyType, false)
+
+	case *ast.StructStatement:
+		c.structStatement(stmt)
+
+	case *ast.EnumStatement:
+		c.declare(stmt.Name.Value, &Type{Kind: EnumType, Name: stmt.Name.Value}, false)
+
+	case *ast.BlockStatement:
+		return c.block(stmt)
+	}
+
+	return NullType
+}
+
+/**
+ * 関数名: Checker.letStatement
+ * 処理: let文・const文を検査する
+ *  型注釈があれば、値の型が代入できるかを検査し、変数の型を型注釈の型とする
+ * 引数: let文
+ * 戻値: なし
+ */
+func (c *Checker) letStatement(stmt *ast.LetStatement) {
+
+	var declared *Type
+
+	if stmt.Name.Type != nil {
+		declared = c.resolve(stmt.Name.Type)
+	}
+
+	// 再帰呼び出しを検査できるよう、関数は値を検査する前に型注釈から求めた型で宣言する
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok && declared == nil {
+		c.declare(stmt.Name.Value, c.signature(fl), false)
+	}
+
+	value := c.expression(stmt.Value)
+
+	if declared == nil {
+		c.declare(stmt.Name.Value, value, false)
+		return
+	}
+
+	if !assignable(declared, value) {
+		c.errorf(stmt.Value.Pos(), "cannot use %s as %s in declaration of %s", value, declared, stmt.Name.Value)
+	}
+
+	c.declare(stmt.Name.Value, declared, true)
+}
+
+/**
+ * 関数名: Checker.returnStatement
+ * 処理: return文を検査する。関数に戻り値の型注釈があれば、戻り値の型を検査する
+ * 引数: return文
+ * 戻値: なし
+ */
+func (c *Checker) returnStatement(stmt *ast.ReturnStatement) {
+
+	value := c.expression(stmt.ReturnValue)
+
+	if len(c.frames) == 0 {
+		return
+	}
+
+	f := c.frames[len(c.frames)-1]
+	f.returned = true
+
+	if f.ret != nil && !assignable(f.ret, value) {
+		c.errorf(stmt.ReturnValue.Pos(), "cannot return %s from function returning %s", value, f.ret)
+	}
+}
+
+/**
+ * 関数名: Checker.structStatement
+ * 処理: 構造体の定義を検査する
+ *  構造体の名前はフィールドを引数にとるコンストラクタとし、メソッドは self を構造体の型として検査する
+ * 引数: struct文
+ * 戻値: なし
+ */
+func (c *Checker) structStatement(stmt *ast.StructStatement) {
+
+	self := namedOf(stmt.Name.Value)
+
+	params := make([]*Type, len(stmt.Fields))
+
+	for i := range params {
+		params[i] = AnyType
+	}
+
+	c.declare(stmt.Name.Value, funcOf(params, self), false)
+
+	info := c.structs[stmt.Name.Value]
+
+	// メソッドどうしで呼び出せるよう、先にすべてのメソッドの型を登録する
+	for _, m := range stmt.Methods {
+		info.methods[m.Name.Value] = c.signature(m.Function)
+	}
+
+	for _, m := range stmt.Methods {
+		c.function(m.Function, self)
+	}
+}
+
+/**
+ * 関数名: Checker.block
+ * 処理: ブロック文を新しいスコープで検査する
+ * 引数: ブロック文
+ * 戻値: 最後の文の型
+ */
+func (c *Checker) block(block *ast.BlockStatement) *Type {
+
+	c.enterScope()
+	defer c.leaveScope()
+
+	var result *Type = NullType
+
+	for _, stmt := range block.Statements {
+		result = c.statement(stmt)
+	}
+
+	return result
+}
+
+/**
+ * 関数名: Checker.function
+ * 処理: 関数リテラルを検査し、関数の型を返す
+ *  戻り値の型注釈が無い場合、return文が無ければ本体の最後の式の型を戻り値の型とする
+ * 引数: 関数リテラル, メソッドの場合はレシーバの型 ( それ以外は nil )
+ * 戻値: 関数の型
+ */
+func (c *Checker) function(fl *ast.FunctionLiteral, self *Type) *Type {
+
+	sig := c.signature(fl)
+
+	c.enterScope()
+	defer c.leaveScope()
+
+	if self != nil {
+		c.declare("self", self, false)
+	}
+
+	for i, p := range fl.Parameters {
+		c.declare(p.Value, sig.Params[i], p.Type != nil)
+	}
+
+	f := &frame{}
+
+	if fl.ReturnType != nil {
+		f.ret = sig.Return
+	}
+
+	c.frames = append(c.frames, f)
+	body := c.block(fl.Body)
+	c.frames = c.frames[:len(c.frames)-1]
+
+	// 最後の式の値は暗黙の戻り値になる
+	if f.ret != nil {
+
+		if last := lastExpression(fl.Body); last != nil && !assignable(f.ret, body) {
+			c.errorf(last.Pos(), "cannot return %s from function returning %s", body, f.ret)
+		}
+
+		return sig
+	}
+
+	if !f.returned {
+		return funcOf(sig.Params, body)
+	}
+
+	return sig
+}
+
+/**
+ * 関数名: lastExpression
+ * 処理: ブロックの最後の文が式文であれば、その式を返す
+ * 引数: ブロック文
+ * 戻値: 式 : 最後の文が式文でなければ nil
+ */
+func lastExpression(block *ast.BlockStatement) ast.Expression {
+
+	if len(block.Statements) == 0 {
+		return nil
+	}
+
+	if stmt, ok := block.Statements[len(block.Statements)-1].(*ast.ExpressionStatement); ok {
+		return stmt.Expression
+	}
+
+	return nil
+}
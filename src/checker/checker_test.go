@@ -0,0 +1,153 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/MasaruFukazawa/monkey-lang/src/lexer"
+	"github.com/MasaruFukazawa/monkey-lang/src/parser"
+)
+
+func testCheck(t *testing.T, input string) []*Error {
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+
+	return Check(program)
+}
+
+// 誤りの無いプログラムでは何も報告しないことをテストする
+func TestCheckValidPrograms(t *testing.T) {
+
+	tests := []string{
+		`let x: int = 1 + 2; x * 3`,
+		`let add = fn(a: int, b: int) -> int { a + b }; add(1, add(2, 3))`,
+		`let greet = fn(name: string) -> string { "hello " + name }; greet("monkey")`,
+		`let fact = fn(n: int) -> int { if (n < 2) { return 1 }; n * fact(n - 1) }; fact(5)`,
+		`let xs: array[int] = [1, 2, 3]; let first: int = xs[0]`,
+		`let h: hash[string, int] = {"a": 1}; let v: int = h["a"]`,
+		`let apply = fn(f: fn(int) -> int, x: int) -> int { f(x) }; apply(fn(n) { n * 2 }, 3)`,
+		`let f = fn(x) { x }; f("a") + f(1)`,
+		`let x = 1; let g = fn() { x = "s" }; g(); x + "b"`,
+		`let any: any = 1; any = "now a string"`,
+		`struct Point { x, y fn norm() -> int { self.x * self.x } }; let p: Point = Point(1, 2); p.norm() + p.x`,
+		`enum Status { Pending, Done(result) }; let s: Status = Status.Done(1); match (s) { Status.Done(r) => r, Status.Pending => 0 }`,
+		`let inc = (n: int) => n + 1; 2 |> inc`,
+		`let ys = [x * 2 for x in [1, 2, 3]]; let y: int = ys[0]`,
+		`try { 1 / 0 } catch (e) { e["message"] + "!" }`,
+		`let n: int = len("abc")`,
+		`let later: Later = Later(1); struct Later { a }`,
+		`let p: tuple[int, string] = (1, "a"); let n: int = p[0]; let str: string = p[1]`,
+		`let t: tuple = (1, 2, 3); match ((1, "a")) { (n, s) => s + "b" }`,
+		`let b: bytes = b"ab" + from_hex("00"); let n: int = b[0] + len(b); let s: string = read_file("x")`,
+		`let b: bytes = from_text("aGk=", "base64") + from_text("00", "hex")`,
+		`let n: int = bytes_len("日本") + len("日本"); let gs: array[string] = graphemes("が")`,
+		`let s: set[int] = #{1, 2}; let ok: bool = 1 in s; let xs: array[int] = [x for x in s]`,
+	}
+
+	for _, input := range tests {
+
+		errors := testCheck(t, input)
+
+		if len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %v", input, errors)
+		}
+	}
+}
+
+// 型の誤りを位置とともに報告することをテストする
+func TestCheckErrors(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let x: int = "hello";`, "1:14: cannot use string as int in declaration of x"},
+		{`"a" + 1`, "1:5: type mismatch: string + int"},
+		{`"a" - "b"`, "1:5: unknown operator: string - string"},
+		{`-true`, "1:1: unknown operator: -bool"},
+		{"let add = fn(a: int, b: int) -> int { a + b };\nadd(1, \"two\")", "2:8: cannot use string as int in argument 2"},
+		{`let add = fn(a: int, b: int) { a + b }; add(1)`, "1:44: wrong number of arguments. got=1, want=2"},
+		{`let f = fn() -> string { 1 }`, "1:26: cannot return int from function returning string"},
+		{`let f = fn(n: int) -> int { if (n > 0) { return "pos" }; n }`, "1:49: cannot return string from function returning int"},
+		{`let x: int = 1; x = "a"`, "1:21: cannot assign string to x of type int"},
+		{`let x: foo = 1`, "1:8: unknown type: foo"},
+		{`let x: array[int, int] = []`, "1:8: wrong number of type arguments for array: got=2"},
+		{`let xs: array[int] = ["a"]`, "1:22: cannot use array[string] as array[int] in declaration of xs"},
+		{`let x = 1; x(2)`, "1:13: not a function: int"},
+		{`let n = 1; n[0]`, "1:13: index operator not supported: int"},
+		{`[1, 2]["a"]`, "1:7: cannot index array with string"},
+		{`struct Point { x }; Point(1).y`, "1:30: unknown member: Point.y"},
+		{`struct Point { x }; Point(1, 2)`, "1:26: wrong number of arguments. got=2, want=1"},
+		{`enum Status { Pending }; Status.Done`, "1:33: unknown variant: Status.Done"},
+		{`enum Status { Done(r) }; match (1) { Status.Done(a, b) => a }`, "1:38: wrong number of payload patterns for Status.Done: got=2, want=1"},
+		{`let f = fn(x: int) -> int { x }; "a" |> f`, "1:34: cannot use string as int in argument 1"},
+		{`[x for x in 5]`, "1:13: not iterable: int"},
+		{`let p: tuple[int, int] = (1, "a")`, "1:26: cannot use tuple[int, string] as tuple[int, int] in declaration of p"},
+		{`let p = (1, "a"); p[1] + 1`, "1:24: type mismatch: string + int"},
+		{`(1, 2)["a"]`, "1:7: cannot index tuple with string"},
+		{`match ((1, "a")) { (n, s) => n + s }`, "1:32: type mismatch: int + string"},
+		{`let s: string = b"ab"`, "1:17: cannot use bytes as string in declaration of s"},
+		{`b"ab" + "c"`, "1:7: type mismatch: bytes + string"},
+		{`from_hex(1)`, "1:10: cannot use int as string in argument 1"},
+		{`let s: string = from_text("aGk=", "base64")`, "1:26: cannot use bytes as string in declaration of s"},
+		{`from_text("aGk=", 64)`, "1:19: cannot use int as string in argument 2"},
+		{`let gs: array[int] = graphemes("日本")`, "1:31: cannot use array[string] as array[int] in declaration of gs"},
+		{`let s: set[string] = #{1, 2}`, "1:22: cannot use set[int] as set[string] in declaration of s"},
+		{`let n: int = 1 in [1]`, "1:16: cannot use bool as int in declaration of n"},
+		{`let g = fn(f: fn(int) -> int) { f(1) }; g(fn(s: string) -> int { 1 })`, "1:43: cannot use fn(string) -> int as fn(int) -> int in argument 1"},
+	}
+
+	for _, tt := range tests {
+
+		errors := testCheck(t, tt.input)
+
+		if len(errors) != 1 {
+			t.Errorf("wrong number of errors for %q. want=1, got=%d (%v)", tt.input, len(errors), errors)
+			continue
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, errors[0].Error())
+		}
+	}
+}
+
+// 誤りを位置の順に報告することをテストする
+func TestCheckErrorOrder(t *testing.T) {
+
+	input := `
+let b: bool = 1;
+let a: int = "x";
+let f = fn(s: string) { s };
+f(1) + f(2)
+`
+
+	expected := []string{
+		"2:15: cannot use int as bool in declaration of b",
+		"3:14: cannot use string as int in declaration of a",
+		"5:3: cannot use int as string in argument 1",
+		"5:10: cannot use int as string in argument 1",
+	}
+
+	errors := testCheck(t, input)
+
+	got := []string{}
+
+	for _, e := range errors {
+		got = append(got, e.Error())
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("wrong number of errors. want=%d, got=%d (%v)", len(expected), len(got), got)
+	}
+
+	for i, want := range expected {
+		if got[i] != want {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, want, got[i])
+		}
+	}
+}
@@ -0,0 +1,729 @@
+/**
+ * パッケージ名: checker
+ * ファイル名: expressions.go
+ * 概要: 式の型を推論し、誤りを検査する
+ */
+package checker
+
+import (
+	"github.com/MasaruFukazawa/monkey-lang/src/ast"
+	"github.com/MasaruFukazawa/monkey-lang/src/token"
+)
+
+/**
+ * 関数名: Checker.expression
+ * 処理: 式を検査し、式の型を返す
+ * 引数: 式
+ * 戻値: 型 : 推論できない場合は any
+ */
+func (c *Checker) expression(node ast.Expression) *Type {
+
+	switch node := node.(type) {
+
+	case *ast.IntegerLiteral:
+		return IntType
+
+	case *ast.StringLiteral:
+		return StringType
+
+	case *ast.Boolean:
+		return BoolType
+
+	case *ast.NullLiteral:
+		return NullType
+
+	case *ast.Identifier:
+		if b, ok := c.lookup(node.Value); ok {
+			return b.typ
+		}
+		return AnyType
+
+	case *ast.PrefixExpression:
+		return c.prefixExpression(node)
+
+	case *ast.InfixExpression:
+		return c.infixExpression(node)
+
+	case *ast.AssignExpression:
+		return c.assignExpression(node)
+
+	case *ast.IfExpression:
+		c.expression(node.Condition)
+
+		consequence := c.block(node.Consequence)
+
+		if node.Alternative == nil {
+			return join(consequence, NullType)
+		}
+
+		return join(consequence, c.block(node.Alternative))
+
+	case *ast.FunctionLiteral:
+		return c.function(node, nil)
+
+	case *ast.CallExpression:
+		function := c.expression(node.Function)
+		return c.call(node.Pos(), function, c.expressions(node.Arguments), positions(node.Arguments))
+
+	case *ast.PipeExpression:
+		left := c.expression(node.Left)
+
+		// 右辺が呼び出し式であれば、左辺の値を第1引数として呼び出す
+		if call, ok := node.Right.(*ast.CallExpression); ok {
+			function := c.expression(call.Function)
+			args := append([]*Type{left}, c.expressions(call.Arguments)...)
+			pos := append([]token.Position{node.Left.Pos()}, positions(call.Arguments)...)
+			return c.call(call.Pos(), function, args, pos)
+		}
+
+		return c.call(node.Pos(), c.expression(node.Right), []*Type{left}, []token.Position{node.Left.Pos()})
+
+	case *ast.ArrayLiteral:
+		return arrayOf(c.elementType(node.Elements))
+
+	case *ast.SetLiteral:
+		return setOf(c.elementType(node.Elements))
+
+	case *ast.BytesLiteral:
+		return BytesType
+
+	case *ast.TupleLiteral:
+		return tupleOf(c.expressions(node.Elements))
+
+	case *ast.HashLiteral:
+		var key, value *Type
+
+		for _, k := range node.Keys {
+
+			kt, vt := c.expression(k), c.expression(node.Pairs[k])
+
+			if key == nil {
+				key, value = kt, vt
+			} else {
+				key, value = join(key, kt), join(value, vt)
+			}
+		}
+
+		if key == nil {
+			return hashOf(AnyType, AnyType)
+		}
+
+		return hashOf(key, value)
+
+	case *ast.IndexExpression:
+		return c.indexExpression(node)
+
+	case *ast.SliceExpression:
+		left := c.expression(node.Left)
+
+		for _, bound := range []ast.Expression{node.Start, node.End, node.Step} {
+			if bound != nil {
+				c.expression(bound)
+			}
+		}
+
+		if left.Kind == Array || left.Kind == String || left.Kind == Bytes {
+			return left
+		}
+
+		// タプルのスライスは、要素の数が分からない
+		if left.Kind == Tuple {
+			return tupleOf(nil)
+		}
+
+		return AnyType
+
+	case *ast.MemberExpression:
+		return c.memberExpression(node)
+
+	case *ast.MatchExpression:
+		return c.matchExpression(node)
+
+	case *ast.ListComprehension:
+		c.enterScope()
+		defer c.leaveScope()
+
+		c.comprehensionClauses(node.Clauses)
+
+		return arrayOf(c.expression(node.Element))
+
+	case *ast.HashComprehension:
+		c.enterScope()
+		defer c.leaveScope()
+
+		c.comprehensionClauses(node.Clauses)
+
+		return hashOf(c.expression(node.Key), c.expression(node.Value))
+
+	case *ast.TryExpression:
+		result := c.block(node.Block)
+
+		if node.Catch != nil {
+
+			c.enterScope()
+
+			if node.Parameter != nil {
+				c.declare(node.Parameter.Value, hashOf(StringType, AnyType), false)
+			}
+
+			result = join(result, c.block(node.Catch))
+
+			c.leaveScope()
+		}
+
+		if node.Finally != nil {
+			c.block(node.Finally)
+		}
+
+		return result
+
+	default:
+		return AnyType
+	}
+}
+
+/**
+ * 関数名: Checker.expressions
+ * 処理: 式のリストを検査し、それぞれの型を返す
+ * 引数: 式のリスト
+ * 戻値: 型のリスト
+ */
+func (c *Checker) expressions(nodes []ast.Expression) []*Type {
+
+	types := make([]*Type, 0, len(nodes))
+
+	for _, node := range nodes {
+		types = append(types, c.expression(node))
+	}
+
+	return types
+}
+
+/**
+ * 関数名: Checker.elementType
+ * 処理: 配列・セットのリテラルの要素を検査し、要素の型をまとめた型を返す
+ * 引数: 要素の式
+ * 戻値: 要素の型 : 要素がない場合は any
+ */
+func (c *Checker) elementType(nodes []ast.Expression) *Type {
+
+	var elem *Type
+
+	for _, el := range c.expressions(nodes) {
+		if elem == nil {
+			elem = el
+		} else {
+			elem = join(elem, el)
+		}
+	}
+
+	if elem == nil {
+		return AnyType
+	}
+
+	return elem
+}
+
+/**
+ * 関数名: positions
+ * 処理: 式のリストの、それぞれの式の位置を返す
+ * 引数: 式のリスト
+ * 戻値: 位置のリスト
+ */
+func positions(nodes []ast.Expression) []token.Position {
+
+	pos := make([]token.Position, 0, len(nodes))
+
+	for _, node := range nodes {
+		pos = append(pos, node.Pos())
+	}
+
+	return pos
+}
+
+/**
+ * 関数名: Checker.prefixExpression
+ * 処理: 前置演算子の式を検査する
+ * 引数: 前置演算子の式
+ * 戻値: 型
+ */
+func (c *Checker) prefixExpression(node *ast.PrefixExpression) *Type {
+
+	right := c.expression(node.Right)
+
+	switch node.Operator {
+	case "!":
+		return BoolType
+	case "-":
+		if right.Kind == Int || right.Kind == Any {
+			return right
+		}
+		c.errorf(node.Pos(), "unknown operator: -%s", right)
+	}
+
+	return AnyType
+}
+
+/**
+ * 関数名: Checker.infixExpression
+ * 処理: 中置演算子の式を検査する
+ *  整数どうしの算術・比較と、文字列どうしの連結だけを許し、型の異なる値の演算を誤りとする
+ * 引数: 中置演算子の式
+ * 戻値: 型
+ */
+func (c *Checker) infixExpression(node *ast.InfixExpression) *Type {
+
+	left := c.expression(node.Left)
+	right := c.expression(node.Right)
+
+	switch node.Operator {
+	case "==", "!=", "in":
+		return BoolType
+	case "??":
+		if left.Kind == Null {
+			return right
+		}
+		return join(left, right)
+	}
+
+	comparison := node.Operator == "<" || node.Operator == ">"
+
+	if left.Kind == Any || right.Kind == Any {
+
+		if comparison {
+			return BoolType
+		}
+
+		return AnyType
+	}
+
+	switch {
+	case left.Kind == Int && right.Kind == Int:
+		if comparison {
+			return BoolType
+		}
+		return IntType
+	case left.Kind != right.Kind:
+		c.errorf(node.Pos(), "type mismatch: %s %s %s", left, node.Operator, right)
+	case left.Kind == String && node.Operator == "+":
+		return StringType
+	case left.Kind == Bytes && node.Operator == "+":
+		return BytesType
+	default:
+		c.errorf(node.Pos(), "unknown operator: %s %s %s", left, node.Operator, right)
+	}
+
+	return AnyType
+}
+
+/**
+ * 関数名: Checker.assignExpression
+ * 処理: 代入式を検査する。型注釈で宣言した変数には、代入する値の型を検査する
+ * 引数: 代入式
+ * 戻値: 代入する値の型
+ */
+func (c *Checker) assignExpression(node *ast.AssignExpression) *Type {
+
+	value := c.expression(node.Value)
+
+	b, ok := c.lookup(node.Name.Value)
+
+	if ok && b.annotated && !assignable(b.typ, value) {
+		c.errorf(node.Value.Pos(), "cannot assign %s to %s of type %s", value, node.Name.Value, b.typ)
+	}
+
+	// 型注釈の無い変数は、代入した値の型も取りうるよう型を広げる
+	if ok && !b.annotated {
+		c.widen(node.Name.Value, value)
+	}
+
+	return value
+}
+
+/**
+ * 関数名: Checker.call
+ * 処理: 関数の呼び出しを検査する。パラメータの型が分かっていれば、引数の数と型を検査する
+ * 引数: 呼び出しの位置, 関数の型, 引数の型, 引数の位置
+ * 戻値: 戻り値の型
+ */
+func (c *Checker) call(pos token.Position, function *Type, args []*Type, argPos []token.Position) *Type {
+
+	switch function.Kind {
+	case Any:
+		return AnyType
+	case Func:
+	default:
+		c.errorf(pos, "not a function: %s", function)
+		return AnyType
+	}
+
+	if function.Params == nil {
+		return function.Return
+	}
+
+	if len(args) != len(function.Params) {
+		c.errorf(pos, "wrong number of arguments. got=%d, want=%d", len(args), len(function.Params))
+		return function.Return
+	}
+
+	for i, arg := range args {
+		if !assignable(function.Params[i], arg) {
+			c.errorf(argPos[i], "cannot use %s as %s in argument %d", arg, function.Params[i], i+1)
+		}
+	}
+
+	return function.Return
+}
+
+/**
+ * 関数名: Checker.indexExpression
+ * 処理: 添字式を検査する
+ * 引数: 添字式
+ * 戻値: 要素の型
+ */
+func (c *Checker) indexExpression(node *ast.IndexExpression) *Type {
+
+	left := c.expression(node.Left)
+	index := c.expression(node.Index)
+
+	switch left.Kind {
+	case Array:
+		if index.Kind != Int && index.Kind != Any {
+			c.errorf(node.Pos(), "cannot index array with %s", index)
+		}
+		return left.Elem
+	case String:
+		if index.Kind != Int && index.Kind != Any {
+			c.errorf(node.Pos(), "cannot index string with %s", index)
+		}
+		return StringType
+	case Bytes:
+		if index.Kind != Int && index.Kind != Any {
+			c.errorf(node.Pos(), "cannot index bytes with %s", index)
+		}
+		return IntType
+	case Tuple:
+		if index.Kind != Int && index.Kind != Any {
+			c.errorf(node.Pos(), "cannot index tuple with %s", index)
+		}
+		return tupleElement(left, node.Index)
+	case Hash:
+		return left.Value
+	case Any:
+		return AnyType
+	case Null:
+		if node.Optional {
+			return NullType
+		}
+	}
+
+	c.errorf(node.Pos(), "index operator not supported: %s", left)
+
+	return AnyType
+}
+
+/**
+ * 関数名: tupleElement
+ * 処理: タプルの要素の型を返す
+ *  添字が整数リテラルの場合はその位置の要素の型、そうでなければ要素の型をまとめた型とする
+ * 引数: タプルの型, 添字の式 ( 位置が分からない場合は nil )
+ * 戻値: 要素の型
+ */
+func tupleElement(tuple *Type, index ast.Expression) *Type {
+
+	if tuple.Elems == nil {
+		return AnyType
+	}
+
+	if lit, ok := index.(*ast.IntegerLiteral); ok {
+
+		idx := lit.Value
+
+		if idx < 0 {
+			idx += int64(len(tuple.Elems))
+		}
+
+		if idx >= 0 && idx < int64(len(tuple.Elems)) {
+			return tuple.Elems[idx]
+		}
+
+		return AnyType
+	}
+
+	var elem *Type
+
+	for _, e := range tuple.Elems {
+		if elem == nil {
+			elem = e
+		} else {
+			elem = join(elem, e)
+		}
+	}
+
+	if elem == nil {
+		return AnyType
+	}
+
+	return elem
+}
+
+/**
+ * 関数名: Checker.memberExpression
+ * 処理: メンバ式を検査する
+ *  構造体のフィールド・メソッドと、列挙型のバリアントを検査する
+ * 引数: メンバ式
+ * 戻値: メンバの型
+ */
+func (c *Checker) memberExpression(node *ast.MemberExpression) *Type {
+
+	obj := c.expression(node.Object)
+	name := node.Property.Value
+
+	switch obj.Kind {
+	case Named:
+		info, ok := c.structs[obj.Name]
+
+		if !ok {
+			// 列挙型の値のペイロード
+			return AnyType
+		}
+
+		if method, ok := info.methods[name]; ok && !info.fields[name] {
+			return method
+		}
+
+		if !info.fields[name] {
+			c.errorf(node.Property.Pos(), "unknown member: %s.%s", obj.Name, name)
+		}
+
+		return AnyType
+
+	case EnumType:
+		fields, ok := c.enums[obj.Name][name]
+
+		if !ok {
+			c.errorf(node.Property.Pos(), "unknown variant: %s.%s", obj.Name, name)
+			return AnyType
+		}
+
+		if fields == 0 {
+			return namedOf(obj.Name)
+		}
+
+		params := make([]*Type, fields)
+
+		for i := range params {
+			params[i] = AnyType
+		}
+
+		return funcOf(params, namedOf(obj.Name))
+
+	case Int, Bool, Func:
+		c.errorf(node.Pos(), "member access not supported: %s", obj)
+	case Null:
+		if !node.Optional {
+			c.errorf(node.Pos(), "member access not supported: %s", obj)
+		}
+		return NullType
+	}
+
+	return AnyType
+}
+
+/**
+ * 関数名: Checker.matchExpression
+ * 処理: match式を検査する。パターンで束縛した変数は腕の中だけで有効にする
+ * 引数: match式
+ * 戻値: 腕の式の型をまとめた型
+ */
+func (c *Checker) matchExpression(node *ast.MatchExpression) *Type {
+
+	subject := c.expression(node.Subject)
+
+	var result *Type
+
+	for _, arm := range node.Arms {
+
+		c.enterScope()
+
+		c.pattern(arm.Pattern, subject)
+
+		if arm.Guard != nil {
+			c.expression(arm.Guard)
+		}
+
+		body := c.expression(arm.Body)
+
+		c.leaveScope()
+
+		if result == nil {
+			result = body
+		} else {
+			result = join(result, body)
+		}
+	}
+
+	if result == nil {
+		return AnyType
+	}
+
+	return result
+}
+
+/**
+ * 関数名: Checker.pattern
+ * 処理: パターンを検査し、パターンで束縛する変数を宣言する
+ * 引数: パターン, 照合する値の型
+ * 戻値: なし
+ */
+func (c *Checker) pattern(pattern ast.Pattern, subject *Type) {
+
+	switch pattern := pattern.(type) {
+
+	case *ast.BindingPattern:
+		c.declare(pattern.Name.Value, subject, false)
+
+	case *ast.ArrayPattern:
+		elem := AnyType
+
+		if subject.Kind == Array {
+			elem = subject.Elem
+		}
+
+		for _, el := range pattern.Elements {
+			c.pattern(el, elem)
+		}
+
+		if pattern.Rest != nil {
+			c.declare(pattern.Rest.Value, arrayOf(elem), false)
+		}
+
+	case *ast.TuplePattern:
+		for i, el := range pattern.Elements {
+
+			elem := AnyType
+
+			if subject.Kind == Tuple && len(subject.Elems) == len(pattern.Elements) {
+				elem = subject.Elems[i]
+			}
+
+			c.pattern(el, elem)
+		}
+
+	case *ast.HashPattern:
+		value := AnyType
+
+		if subject.Kind == Hash {
+			value = subject.Value
+		}
+
+		for _, v := range pattern.Values {
+			c.pattern(v, value)
+		}
+
+	case *ast.EnumPattern:
+		c.enumPattern(pattern)
+	}
+}
+
+/**
+ * 関数名: Checker.enumPattern
+ * 処理: 列挙型のパターンの列挙型・バリアント・ペイロードの数を検査する
+ * 引数: 列挙型のパターン
+ * 戻値: なし
+ */
+func (c *Checker) enumPattern(pattern *ast.EnumPattern) {
+
+	// ペイロードのパターンで束縛する変数は、誤りがあっても宣言しておく
+	defer func() {
+		for _, p := range pattern.Payload {
+			c.pattern(p, AnyType)
+		}
+	}()
+
+	b, ok := c.lookup(pattern.Enum.Value)
+
+	if !ok || b.typ.Kind == Any {
+		return
+	}
+
+	if b.typ.Kind != EnumType {
+		c.errorf(pattern.Pos(), "not an enum: %s", pattern.Enum.Value)
+		return
+	}
+
+	fields, ok := c.enums[b.typ.Name][pattern.Variant.Value]
+
+	if !ok {
+		c.errorf(pattern.Variant.Pos(), "unknown variant: %s.%s", b.typ.Name, pattern.Variant.Value)
+		return
+	}
+
+	if pattern.Payload != nil && len(pattern.Payload) != fields {
+		c.errorf(
+			pattern.Pos(), "wrong number of payload patterns for %s.%s: got=%d, want=%d",
+			b.typ.Name, pattern.Variant.Value, len(pattern.Payload), fields,
+		)
+	}
+}
+
+/**
+ * 関数名: Checker.comprehensionClauses
+ * 処理: 内包表記の for 節を検査し、反復する変数を宣言する
+ * 引数: for 節のリスト
+ * 戻値: なし
+ */
+func (c *Checker) comprehensionClauses(clauses []*ast.ComprehensionClause) {
+
+	for _, clause := range clauses {
+
+		iterable := c.expression(clause.Iterable)
+
+		// 1つ目の変数は要素 ( ハッシュの場合はキー ) 、2つ目の変数は値とする
+		first, second := AnyType, AnyType
+
+		switch iterable.Kind {
+		case Array:
+			first = iterable.Elem
+			if len(clause.Variables) == 2 {
+				first, second = IntType, iterable.Elem
+			}
+		case String:
+			first = StringType
+			if len(clause.Variables) == 2 {
+				first, second = IntType, StringType
+			}
+		case Bytes:
+			first = IntType
+			if len(clause.Variables) == 2 {
+				first, second = IntType, IntType
+			}
+		case Tuple:
+			first = tupleElement(iterable, nil)
+			if len(clause.Variables) == 2 {
+				first, second = IntType, first
+			}
+		case Set:
+			first = iterable.Elem
+			if len(clause.Variables) == 2 {
+				first, second = IntType, iterable.Elem
+			}
+		case Hash:
+			first, second = iterable.Key, iterable.Value
+		case Int, Bool, Null, Func, Named, EnumType:
+			c.errorf(clause.Iterable.Pos(), "not iterable: %s", iterable)
+		}
+
+		for i, v := range clause.Variables {
+			if i == 0 {
+				c.declare(v.Value, first, false)
+			} else {
+				c.declare(v.Value, second, false)
+			}
+		}
+
+		for _, cond := range clause.Conditions {
+			c.expression(cond)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+/**
+ * パッケージ名: checker
+ * ファイル名: types.go
+ * 概要: 静的検査で扱う型を定義する
+ */
+package checker
+
+import (
+	"strings"
+)
+
+// 型の種類
+type Kind int
+
+const (
+	Any      Kind = iota // 型が分からない。どの型とも互換とする
+	Int                  // 整数
+	String               // 文字列
+	Bytes                // バイト列
+	Bool                 // 真偽値
+	Null                 // null
+	Array                // 配列 : Elem に要素の型を持つ
+	Hash                 // ハッシュ : Key, Value にキーと値の型を持つ
+	Set                  // セット : Elem に要素の型を持つ
+	Tuple                // タプル : Elems に要素の型を持つ
+	Func                 // 関数 : Params, Return にパラメータと戻り値の型を持つ
+	Named                // 構造体のインスタンス・列挙型の値 : Name に型名を持つ
+	EnumType             // 列挙型そのもの ( Status.Done の Status ) : Name に型名を持つ
+)
+
+// 型を表す構造体
+type Type struct {
+	Kind   Kind
+	Name   string  // Named, EnumType の型名
+	Elem   *Type   // Array, Set の要素の型
+	Elems  []*Type // Tuple の要素の型 : nil の場合は要素の数も分からない
+	Key    *Type   // Hash のキーの型
+	Value  *Type   // Hash の値の型
+	Params []*Type // Func のパラメータの型 : nil の場合は引数を検査しない
+	Return *Type   // Func の戻り値の型
+}
+
+// よく使う型
+var (
+	AnyType    = &Type{Kind: Any}
+	IntType    = &Type{Kind: Int}
+	StringType = &Type{Kind: String}
+	BytesType  = &Type{Kind: Bytes}
+	BoolType   = &Type{Kind: Bool}
+	NullType   = &Type{Kind: Null}
+)
+
+// 型注釈で使える基本の型名
+var basicTypes = map[string]*Type{
+	"any":    AnyType,
+	"int":    IntType,
+	"string": StringType,
+	"bytes":  BytesType,
+	"bool":   BoolType,
+	"null":   NullType,
+}
+
+/**
+ * 名前: Type.String
+ * 処理: 型を型注釈と同じ形式の文字列にして返す
+ * 引数: なし
+ * 戻り値: string
+ */
+func (t *Type) String() string {
+
+	switch t.Kind {
+	case Int:
+		return "int"
+	case String:
+		return "string"
+	case Bytes:
+		return "bytes"
+	case Bool:
+		return "bool"
+	case Null:
+		return "null"
+	case Array:
+		return "array[" + t.Elem.String() + "]"
+	case Hash:
+		return "hash[" + t.Key.String() + ", " + t.Value.String() + "]"
+	case Set:
+		return "set[" + t.Elem.String() + "]"
+	case Tuple:
+		if t.Elems == nil {
+			return "tuple"
+		}
+
+		elems := []string{}
+
+		for _, e := range t.Elems {
+			elems = append(elems, e.String())
+		}
+
+		return "tuple[" + strings.Join(elems, ", ") + "]"
+	case Func:
+		params := []string{}
+
+		for _, p := range t.Params {
+			params = append(params, p.String())
+		}
+
+		return "fn(" + strings.Join(params, ", ") + ") -> " + t.Return.String()
+	case Named:
+		return t.Name
+	case EnumType:
+		return "enum " + t.Name
+	default:
+		return "any"
+	}
+}
+
+/**
+ * 名前: assignable
+ * 処理: value の型の値を、target の型の変数に代入できるかどうかを判定する
+ *  どちらかが any の場合は代入できるものとする
+ * 引数: 代入先の型, 代入する値の型
+ * 戻り値: bool
+ */
+func assignable(target, value *Type) bool {
+
+	if target.Kind == Any || value.Kind == Any {
+		return true
+	}
+
+	if target.Kind != value.Kind {
+		return false
+	}
+
+	switch target.Kind {
+	case Array, Set:
+		return assignable(target.Elem, value.Elem)
+	case Hash:
+		return assignable(target.Key, value.Key) && assignable(target.Value, value.Value)
+	case Tuple:
+		if target.Elems == nil || value.Elems == nil {
+			return true
+		}
+
+		if len(target.Elems) != len(value.Elems) {
+			return false
+		}
+
+		for i := range target.Elems {
+			if !assignable(target.Elems[i], value.Elems[i]) {
+				return false
+			}
+		}
+
+		return true
+	case Func:
+		if target.Params != nil && value.Params != nil {
+
+			if len(target.Params) != len(value.Params) {
+				return false
+			}
+
+			for i := range target.Params {
+				if !assignable(value.Params[i], target.Params[i]) {
+					return false
+				}
+			}
+		}
+
+		return assignable(target.Return, value.Return)
+	case Named, EnumType:
+		return target.Name == value.Name
+	default:
+		return true
+	}
+}
+
+/**
+ * 名前: join
+ * 処理: 2つの型をまとめた型を返す。互いに異なる場合は any とする
+ * 引数: 型, 型
+ * 戻り値: *Type
+ */
+func join(a, b *Type) *Type {
+
+	if assignable(a, b) && assignable(b, a) && a.Kind != Any {
+		return a
+	}
+
+	return AnyType
+}
+
+/**
+ * 名前: arrayOf, setOf, tupleOf, hashOf, funcOf, namedOf
+ * 処理: 型を組み立てる
+ */
+func arrayOf(elem *Type) *Type {
+	return &Type{Kind: Array, Elem: elem}
+}
+
+func setOf(elem *Type) *Type {
+	return &Type{Kind: Set, Elem: elem}
+}
+
+func tupleOf(elems []*Type) *Type {
+	return &Type{Kind: Tuple, Elems: elems}
+}
+
+func hashOf(key, value *Type) *Type {
+	return &Type{Kind: Hash, Key: key, Value: value}
+}
+
+func funcOf(params []*Type, ret *Type) *Type {
+	return &Type{Kind: Func, Params: params, Return: ret}
+}
+
+func namedOf(name string) *Type {
+	return &Type{Kind: Named, Name: name}
+}
@@ -1,6 +1,10 @@
 package evaluator
 
 import (
+	"fmt"
+	"os"
+	"unicode/utf8"
+
 	"github.com/MasaruFukazawa/monkey-lang/src/object"
 )
 
@@ -10,16 +14,22 @@ var builtins = map[string]*object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 
 			switch arg := args[0].(type) {
 			case *object.Array:
-				return &object.Integer{Value: int64(len(arg.Elements))}
+				return object.NewInteger(int64(arg.Len()))
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return object.NewInteger(int64(utf8.RuneCountInString(arg.Value)))
+			case *object.Tuple:
+				return object.NewInteger(int64(len(arg.Elements)))
+			case *object.Bytes:
+				return object.NewInteger(int64(len(arg.Value)))
+			case *object.Set:
+				return object.NewInteger(int64(arg.Len()))
 			default:
-				return newError("argument to `len` not supported, got=%s", args[0].Type())
+				return newError(argumentErrorKind, "argument to `len` not supported, got=%s", args[0].Type())
 			}
 		},
 	},
@@ -27,17 +37,17 @@ var builtins = map[string]*object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `first` must bu ARRAY, got %s", args[0].Type())
+				return newError(argumentErrorKind, "argument to `first` must bu ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
 
-			if len(arr.Elements) > 0 {
-				return arr.Elements[0]
+			if arr.Len() > 0 {
+				return arr.At(0)
 			}
 
 			return NULL
@@ -47,43 +57,49 @@ var builtins = map[string]*object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `first` must bu ARRAY, got %s", args[0].Type())
+				return newError(argumentErrorKind, "argument to `first` must bu ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
 
-			length := len(arr.Elements)
+			length := arr.Len()
 
 			if length > 0 {
-				return arr.Elements[length-1]
+				return arr.At(length - 1)
 			}
 
 			return NULL
 		},
 	},
-	"rust": &object.Builtin{
+	"rest": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `first` must bu ARRAY, got %s", args[0].Type())
+				return newError(argumentErrorKind, "argument to `first` must bu ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
 
-			length := len(arr.Elements)
+			if rest := arr.Rest(); rest != nil {
+				return rest
+			}
 
-			if length > 0 {
-				newElements := make([]object.Object, length-1, length-1)
-				copy(newElements, arr.Elements[1:length])
-				return &object.Array{Elements: newElements}
+			return NULL
+		},
+	},
+	"puts": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+
+			for _, arg := range args {
+				fmt.Println(arg.Inspect())
 			}
 
 			return NULL
@@ -93,24 +109,226 @@ var builtins = map[string]*object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 
 			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=2", len(args))
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=2", len(args))
 			}
 
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to `first` must bu ARRAY, got %s", args[0].Type())
+				return newError(argumentErrorKind, "argument to `first` must bu ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
 
-			length := len(arr.Elements)
+			return arr.Push(args[1])
+		},
+	},
+	"set": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Array:
+				return newSet(arg.Elements())
+			case *object.Set:
+				return object.NewSet(arg.Elements()...)
+			default:
+				return newError(argumentErrorKind, "argument to `set` must be ARRAY or SET, got %s", args[0].Type())
+			}
+		},
+	},
+	"bytes_len": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError(argumentErrorKind, "argument to `bytes_len` must be STRING, got %s", args[0].Type())
+			}
+
+			return object.NewInteger(int64(len(str.Value)))
+		},
+	},
+	"graphemes": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
 
-			newElements := make([]object.Object, length+1, length+1)
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
 
-			copy(newElements, arr.Elements)
+			str, ok := args[0].(*object.String)
 
-			newElements[length] = args[1]
+			if !ok {
+				return newError(argumentErrorKind, "argument to `graphemes` must be STRING, got %s", args[0].Type())
+			}
 
-			return &object.Array{Elements: newElements}
+			return stringsToArray(graphemes(str.Value))
 		},
 	},
+	"bytes": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+
+			if len(args) < 1 || len(args) > 2 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.String:
+				encoding, err := encodingArgument("bytes", args[1:])
+
+				if err != nil {
+					return err
+				}
+
+				value, err := encodeString(arg.Value, encoding)
+
+				if err != nil {
+					return err
+				}
+
+				return &object.Bytes{Value: value}
+			case *object.Array:
+				if len(args) != 1 {
+					return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				return bytesFromArray(arg)
+			case *object.Bytes:
+				if len(args) != 1 {
+					return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				return arg
+			default:
+				return newError(argumentErrorKind, "argument to `bytes` must be STRING, ARRAY or BYTES, got %s", args[0].Type())
+			}
+		},
+	},
+	"from_hex": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			s, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError(argumentErrorKind, "argument to `from_hex` must be STRING, got %s", args[0].Type())
+			}
+
+			return bytesFromText(s.Value, "hex")
+		},
+	},
+	"from_text": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+
+			if len(args) != 2 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			s, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError(argumentErrorKind, "argument to `from_text` must be STRING, got %s", args[0].Type())
+			}
+
+			format, err := textFormatArgument("from_text", args[1])
+
+			if err != nil {
+				return err
+			}
+
+			return bytesFromText(s.Value, format)
+		},
+	},
+	"read_file": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+
+			path, err := pathArgument("read_file", args, 1)
+
+			if err != nil {
+				return err
+			}
+
+			data, readErr := os.ReadFile(path)
+
+			if readErr != nil {
+				return newError(ioErrorKind, "failed to read file: %s", readErr)
+			}
+
+			return &object.String{Value: string(data)}
+		},
+	},
+	"read_bytes": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+
+			path, err := pathArgument("read_bytes", args, 1)
+
+			if err != nil {
+				return err
+			}
+
+			data, readErr := os.ReadFile(path)
+
+			if readErr != nil {
+				return newError(ioErrorKind, "failed to read file: %s", readErr)
+			}
+
+			return &object.Bytes{Value: data}
+		},
+	},
+	"write_file": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+
+			path, err := pathArgument("write_file", args, 2)
+
+			if err != nil {
+				return err
+			}
+
+			var data []byte
+
+			switch arg := args[1].(type) {
+			case *object.String:
+				data = []byte(arg.Value)
+			case *object.Bytes:
+				data = arg.Value
+			default:
+				return newError(argumentErrorKind, "argument to `write_file` must be STRING or BYTES, got %s", args[1].Type())
+			}
+
+			if writeErr := os.WriteFile(path, data, 0o644); writeErr != nil {
+				return newError(ioErrorKind, "failed to write file: %s", writeErr)
+			}
+
+			return object.NewInteger(int64(len(data)))
+		},
+	},
+}
+
+/**
+ * 関数名: pathArgument
+ * 処理: ファイルを扱う組み込み関数の引数の数と、1つ目の引数がパスの文字列であることを検査する
+ * 引数: 組み込み関数の名前, 引数, 引数の数
+ * 戻値: パス, エラー
+ */
+func pathArgument(name string, args []object.Object, want int) (string, object.Object) {
+
+	if len(args) != want {
+		return "", newError(argumentErrorKind, "wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+
+	path, ok := args[0].(*object.String)
+
+	if !ok {
+		return "", newError(argumentErrorKind, "argument to `%s` must be STRING, got %s", name, args[0].Type())
+	}
+
+	return path.Value, nil
 }
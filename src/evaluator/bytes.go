@@ -0,0 +1,240 @@
+package evaluator
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MasaruFukazawa/monkey-lang/src/object"
+)
+
+/**
+ * 関数名: normalizeEncoding
+ * 処理: 文字コードの名前を、大文字・小文字や区切りの違いを無視して正規化する
+ * 引数: 文字コードの名前
+ * 戻値: 正規化した名前 ( utf-8, ascii, latin-1 ) , 対応している文字コードかどうか
+ */
+func normalizeEncoding(name string) (string, bool) {
+
+	switch strings.ReplaceAll(strings.ToLower(name), "_", "-") {
+	case "utf-8", "utf8":
+		return "utf-8", true
+	case "ascii", "us-ascii":
+		return "ascii", true
+	case "latin-1", "latin1", "iso-8859-1":
+		return "latin-1", true
+	default:
+		return "", false
+	}
+}
+
+/**
+ * 関数名: encodingArgument
+ * 処理: 省略できる文字コードの引数を検査する。省略した場合は utf-8 とする
+ * 引数: 呼び出した関数名, 引数
+ * 戻値: 正規化した文字コードの名前, エラー
+ */
+func encodingArgument(name string, args []object.Object) (string, object.Object) {
+
+	if len(args) == 0 {
+		return "utf-8", nil
+	}
+
+	encodingName, ok := args[0].(*object.String)
+
+	if !ok {
+		return "", newError(argumentErrorKind, "argument to `%s` must be STRING, got %s", name, args[0].Type())
+	}
+
+	encoding, ok := normalizeEncoding(encodingName.Value)
+
+	if !ok {
+		return "", newError(valueErrorKind, "unknown encoding: %s", encodingName.Value)
+	}
+
+	return encoding, nil
+}
+
+/**
+ * 関数名: encodeString
+ * 処理: 文字列を文字コードに従ってバイト列に変換する
+ * 引数: 文字列, 正規化した文字コードの名前
+ * 戻値: バイト列, エラー
+ */
+func encodeString(s string, encoding string) ([]byte, object.Object) {
+
+	if encoding == "utf-8" {
+		return []byte(s), nil
+	}
+
+	limit := rune(0x80)
+
+	if encoding == "latin-1" {
+		limit = 0x100
+	}
+
+	out := make([]byte, 0, len(s))
+
+	for _, r := range s {
+
+		if r >= limit {
+			return nil, newError(valueErrorKind, "cannot encode character %q as %s", r, encoding)
+		}
+
+		out = append(out, byte(r))
+	}
+
+	return out, nil
+}
+
+/**
+ * 関数名: decodeBytes
+ * 処理: バイト列を文字コードに従って文字列に変換する
+ * 引数: バイト列, 正規化した文字コードの名前
+ * 戻値: 文字列, エラー
+ */
+func decodeBytes(b []byte, encoding string) (string, object.Object) {
+
+	switch encoding {
+	case "utf-8":
+		for i := 0; i < len(b); {
+
+			r, size := utf8.DecodeRune(b[i:])
+
+			if r == utf8.RuneError && size <= 1 {
+				return "", newError(valueErrorKind, "cannot decode byte 0x%02x at position %d as %s", b[i], i, encoding)
+			}
+
+			i += size
+		}
+
+		return string(b), nil
+
+	case "ascii":
+		for i, c := range b {
+			if c >= 0x80 {
+				return "", newError(valueErrorKind, "cannot decode byte 0x%02x at position %d as %s", c, i, encoding)
+			}
+		}
+
+		return string(b), nil
+
+	default:
+		runes := make([]rune, len(b))
+
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+
+		return string(runes), nil
+	}
+}
+
+/**
+ * 関数名: textFormatArgument
+ * 処理: バイト列を文字列で表す形式 ( hex, base64 ) の引数を検査する
+ * 引数: 呼び出した関数名, 引数
+ * 戻値: 正規化した形式の名前, エラー
+ */
+func textFormatArgument(name string, arg object.Object) (string, object.Object) {
+
+	format, ok := arg.(*object.String)
+
+	if !ok {
+		return "", newError(argumentErrorKind, "argument to `%s` must be STRING, got %s", name, arg.Type())
+	}
+
+	switch normalized := strings.ToLower(format.Value); normalized {
+	case "hex", "base64":
+		return normalized, nil
+	default:
+		return "", newError(valueErrorKind, "unknown text format: %s", format.Value)
+	}
+}
+
+/**
+ * 関数名: bytesToText
+ * 処理: バイト列を、形式に従って文字列で表す
+ * 引数: バイト列, 正規化した形式の名前
+ * 戻値: 文字列
+ */
+func bytesToText(b []byte, format string) string {
+
+	if format == "hex" {
+		return hex.EncodeToString(b)
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+/**
+ * 関数名: bytesFromText
+ * 処理: 形式に従って文字列で表したバイト列を、元のバイト列に戻す
+ * 引数: 文字列, 正規化した形式の名前
+ * 戻値: 評価結果
+ */
+func bytesFromText(s string, format string) object.Object {
+
+	var value []byte
+	var err error
+
+	if format == "hex" {
+		value, err = hex.DecodeString(s)
+	} else {
+		value, err = base64.StdEncoding.DecodeString(s)
+	}
+
+	if err != nil {
+		return newError(valueErrorKind, "invalid %s string: %s", format, err)
+	}
+
+	return &object.Bytes{Value: value}
+}
+
+/**
+ * 関数名: evalBytesIndexExpression
+ * 処理: バイト列の添字アクセスを評価する。バイトの値を整数として返す
+ * 引数: バイト列, 添字
+ * 戻値: 評価結果 : 範囲外の場合は null
+ */
+func evalBytesIndexExpression(b, index object.Object) object.Object {
+
+	value := b.(*object.Bytes).Value
+
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(value))
+
+	if !ok {
+		return NULL
+	}
+
+	return object.NewInteger(int64(value[idx]))
+}
+
+/**
+ * 関数名: bytesFromArray
+ * 処理: 0 から 255 までの整数の配列からバイト列を生成する
+ * 引数: 配列
+ * 戻値: 評価結果
+ */
+func bytesFromArray(array *object.Array) object.Object {
+
+	out := make([]byte, array.Len())
+
+	for i, el := range array.Elements() {
+
+		n, ok := el.(*object.Integer)
+
+		if !ok {
+			return newError(argumentErrorKind, "argument to `bytes` must be ARRAY of INTEGER, got %s", el.Type())
+		}
+
+		if n.Value < 0 || n.Value > 255 {
+			return newError(valueErrorKind, "byte out of range: %d", n.Value)
+		}
+
+		out[i] = byte(n.Value)
+	}
+
+	return &object.Bytes{Value: out}
+}
@@ -0,0 +1,230 @@
+package evaluator
+
+import (
+	"github.com/MasaruFukazawa/monkey-lang/src/ast"
+	"github.com/MasaruFukazawa/monkey-lang/src/object"
+)
+
+// 反復の1要素を表す構造体
+type iterationItem struct {
+	single object.Object // 変数が1つの場合に束縛する値 ( 配列・文字列は要素、ハッシュはキー )
+	key    object.Object // 変数が2つの場合の1つ目 ( 配列・文字列は位置、ハッシュはキー )
+	value  object.Object // 変数が2つの場合の2つ目 ( 配列・文字列は要素、ハッシュは値 )
+}
+
+/**
+ * 関数名: evalListComprehension
+ * 処理: 配列の内包表記を評価する
+ * 引数: 配列の内包表記, 環境
+ * 戻値: 評価結果
+ */
+func evalListComprehension(node *ast.ListComprehension, env *object.Environment) object.Object {
+
+	elements := []object.Object{}
+
+	err := evalComprehensionClauses(node.Clauses, env, func(scope *object.Environment) object.Object {
+
+		element := Eval(node.Element, scope)
+
+		if isError(element) {
+			return element
+		}
+
+		elements = append(elements, element)
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return object.NewArray(elements)
+}
+
+/**
+ * 関数名: evalHashComprehension
+ * 処理: ハッシュの内包表記を評価する
+ * 引数: ハッシュの内包表記, 環境
+ * 戻値: 評価結果
+ */
+func evalHashComprehension(node *ast.HashComprehension, env *object.Environment) object.Object {
+
+	hash := object.NewHash()
+
+	err := evalComprehensionClauses(node.Clauses, env, func(scope *object.Environment) object.Object {
+
+		key := Eval(node.Key, scope)
+
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := object.AsHashable(key)
+
+		if !ok {
+			return newError(keyErrorKind, "unusable as hash key: %s", key.Type())
+		}
+
+		value := Eval(node.Value, scope)
+
+		if isError(value) {
+			return value
+		}
+
+		hash.Set(hashKey, value)
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return hash
+}
+
+/**
+ * 関数名: evalComprehensionClauses
+ * 処理: 内包表記のfor節を外側から順に反復し、すべての条件を満たした要素ごとにyieldを呼び出す
+ *  内側のfor節の反復対象と条件は、外側の要素ごとに評価する
+ *  条件は先頭から順に評価し、偽になった時点で残りの条件と要素の式は評価しない
+ * 引数: for節の一覧, 環境, 要素ごとに呼び出す関数
+ * 戻値: エラー。エラーが無ければnil
+ */
+func evalComprehensionClauses(
+	clauses []*ast.ComprehensionClause,
+	env *object.Environment,
+	yield func(scope *object.Environment) object.Object,
+) object.Object {
+
+	if len(clauses) == 0 {
+		return yield(env)
+	}
+
+	clause := clauses[0]
+
+	iterable := Eval(clause.Iterable, env)
+
+	if isError(iterable) {
+		return iterable
+	}
+
+	items, err := iterate(iterable)
+
+	if err != nil {
+		return err
+	}
+
+	if len(clause.Variables) > 2 {
+		return newError(typeErrorKind, "too many iteration variables: got=%d, want=1 or 2", len(clause.Variables))
+	}
+
+outer:
+	for _, item := range items {
+
+		// 反復する変数は要素ごとの新しいスコープに束縛する
+		scope := object.NewEnclosedEnvironment(env)
+
+		if len(clause.Variables) == 1 {
+			scope.Set(clause.Variables[0].Value, item.single)
+		} else {
+			scope.Set(clause.Variables[0].Value, item.key)
+			scope.Set(clause.Variables[1].Value, item.value)
+		}
+
+		for _, cond := range clause.Conditions {
+
+			result := Eval(cond, scope)
+
+			if isError(result) {
+				return result
+			}
+
+			if !isTruthy(result) {
+				continue outer
+			}
+		}
+
+		if result := evalComprehensionClauses(clauses[1:], scope, yield); result != nil {
+			return result
+		}
+	}
+
+	return nil
+}
+
+/**
+ * 関数名: iterate
+ * 処理: 反復できるオブジェクトの要素の一覧を返す
+ * 引数: 反復対象
+ * 戻値: 要素の一覧, エラー
+ */
+func iterate(iterable object.Object) ([]iterationItem, *object.Error) {
+
+	items := []iterationItem{}
+
+	switch iterable := iterable.(type) {
+
+	case *object.Array:
+		for i, el := range iterable.Elements() {
+			items = append(items, iterationItem{
+				single: el,
+				key:    object.NewInteger(int64(i)),
+				value:  el,
+			})
+		}
+
+	case *object.String:
+		for i, r := range []rune(iterable.Value) {
+			ch := &object.String{Value: string(r)}
+			items = append(items, iterationItem{
+				single: ch,
+				key:    object.NewInteger(int64(i)),
+				value:  ch,
+			})
+		}
+
+	case *object.Hash:
+		for _, pair := range iterable.Pairs() {
+			items = append(items, iterationItem{
+				single: pair.Key,
+				key:    pair.Key,
+				value:  pair.Value,
+			})
+		}
+
+	case *object.Tuple:
+		for i, el := range iterable.Elements {
+			items = append(items, iterationItem{
+				single: el,
+				key:    object.NewInteger(int64(i)),
+				value:  el,
+			})
+		}
+
+	case *object.Bytes:
+		for i, c := range iterable.Value {
+			n := object.NewInteger(int64(c))
+			items = append(items, iterationItem{
+				single: n,
+				key:    object.NewInteger(int64(i)),
+				value:  n,
+			})
+		}
+
+	case *object.Set:
+		for i, el := range iterable.Elements() {
+			items = append(items, iterationItem{
+				single: el,
+				key:    object.NewInteger(int64(i)),
+				value:  el,
+			})
+		}
+
+	default:
+		return nil, newError(typeErrorKind, "not iterable: %s", iterable.Type())
+	}
+
+	return items, nil
+}
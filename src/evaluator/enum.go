@@ -0,0 +1,145 @@
+package evaluator
+
+import (
+	"github.com/MasaruFukazawa/monkey-lang/src/ast"
+	"github.com/MasaruFukazawa/monkey-lang/src/object"
+)
+
+/**
+ * 関数名: evalEnumStatement
+ * 処理: 列挙型の定義を評価し、列挙型を名前に束縛する
+ * 引数: enum文, 環境
+ * 戻値: エラー : 束縛できた場合は nil
+ */
+func evalEnumStatement(es *ast.EnumStatement, env *object.Environment) object.Object {
+
+	if env.IsConst(es.Name.Value) {
+		return newError(nameErrorKind, "cannot redeclare constant %s", es.Name.Value)
+	}
+
+	et := &object.EnumType{Name: es.Name.Value}
+
+	for _, v := range es.Variants {
+
+		variant := &object.EnumVariant{Name: v.Name.Value}
+
+		for _, field := range v.Fields {
+			variant.Fields = append(variant.Fields, field.Value)
+		}
+
+		et.Variants = append(et.Variants, variant)
+	}
+
+	env.Set(es.Name.Value, et)
+
+	return nil
+}
+
+/**
+ * 関数名: evalEnumMember
+ * 処理: 列挙型のバリアントを返す
+ *  ペイロードを持たないバリアントはその値を、持つバリアントは値を生成する関数を返す
+ * 引数: 列挙型, バリアント名
+ * 戻値: 評価結果
+ */
+func evalEnumMember(et *object.EnumType, name string) object.Object {
+
+	variant, ok := et.Variant(name)
+
+	if !ok {
+		return newError(memberErrorKind, "unknown variant: %s.%s", et.Name, name)
+	}
+
+	if len(variant.Fields) == 0 {
+		return &object.EnumValue{Enum: et, Variant: variant}
+	}
+
+	return &object.VariantConstructor{Enum: et, Variant: variant}
+}
+
+/**
+ * 関数名: newEnumValue
+ * 処理: ペイロードを持つバリアントの値を生成する
+ * 引数: バリアント, ペイロード
+ * 戻値: 評価結果
+ */
+func newEnumValue(vc *object.VariantConstructor, args []object.Object) object.Object {
+
+	if len(args) != len(vc.Variant.Fields) {
+		return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=%d", len(args), len(vc.Variant.Fields))
+	}
+
+	payload := make([]object.Object, len(args))
+	copy(payload, args)
+
+	return &object.EnumValue{Enum: vc.Enum, Variant: vc.Variant, Payload: payload}
+}
+
+/**
+ * 関数名: evalEnumValueMember
+ * 処理: 列挙型の値のペイロードを、フィールド名で参照する
+ * 引数: 列挙型の値, フィールド名
+ * 戻値: 評価結果
+ */
+func evalEnumValueMember(ev *object.EnumValue, name string) object.Object {
+
+	for i, field := range ev.Variant.Fields {
+		if field == name {
+			return ev.Payload[i]
+		}
+	}
+
+	return newError(memberErrorKind, "unknown member: %s.%s.%s", ev.Enum.Name, ev.Variant.Name, name)
+}
+
+/**
+ * 関数名: matchEnumPattern
+ * 処理: 列挙型のパターンと値を照合する
+ *  列挙型とバリアントが一致し、ペイロードがそれぞれのパターンに一致すれば一致とする
+ * 引数: パターン, 値, 環境
+ * 戻値: 一致したかどうか, エラー
+ */
+func matchEnumPattern(pattern *ast.EnumPattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+
+	obj, ok := env.Get(pattern.Enum.Value)
+
+	if !ok {
+		return false, newError(nameErrorKind, "identifier not found: %s", pattern.Enum.Value)
+	}
+
+	et, ok := obj.(*object.EnumType)
+
+	if !ok {
+		return false, newError(typeErrorKind, "not an enum: %s", pattern.Enum.Value)
+	}
+
+	variant, ok := et.Variant(pattern.Variant.Value)
+
+	if !ok {
+		return false, newError(memberErrorKind, "unknown variant: %s.%s", et.Name, pattern.Variant.Value)
+	}
+
+	if pattern.Payload != nil && len(pattern.Payload) != len(variant.Fields) {
+		return false, newError(
+			typeErrorKind, "wrong number of payload patterns for %s.%s: got=%d, want=%d",
+			et.Name, variant.Name, len(pattern.Payload), len(variant.Fields),
+		)
+	}
+
+	ev, ok := value.(*object.EnumValue)
+
+	if !ok || ev.Enum != et || ev.Variant != variant {
+		return false, nil
+	}
+
+	for i, el := range pattern.Payload {
+
+		matched, err := matchPattern(el, ev.Payload[i], env)
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
@@ -25,11 +25,30 @@ func isError(obj object.Object) bool {
 /**
  * 関数名: Eval
  * 処理: 引数で渡された抽象構文木を評価する
+ *  評価結果が位置を持たないエラーであれば、評価したノードの位置を設定する
  * 引数: 抽象構文木
  * 戻値: 評価結果
  */
 func Eval(node ast.Node, env *object.Environment) object.Object {
 
+	result := evalNode(node, env)
+
+	// 最も内側のノードでエラーの位置が決まる
+	if err, ok := result.(*object.Error); ok && !err.Position.IsValid() {
+		err.Position = node.Pos()
+	}
+
+	return result
+}
+
+/**
+ * 関数名: evalNode
+ * 処理: ノードの種類に応じて評価する
+ * 引数: 抽象構文木
+ * 戻値: 評価結果
+ */
+func evalNode(node ast.Node, env *object.Environment) object.Object {
+
 	switch node := node.(type) {
 
 	case *ast.Program:
@@ -39,7 +58,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return Eval(node.Expression, env)
 
 	case *ast.IntegerLiteral:
-		return &object.Integer{Value: node.Value}
+		return object.NewInteger(node.Value)
 
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
@@ -54,6 +73,11 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalPrefixExpression(node.Operator, right)
 
 	case *ast.InfixExpression:
+		// ?? は左辺がnullのときだけ右辺を評価する
+		if node.Operator == "??" {
+			return evalNullishExpression(node, env)
+		}
+
 		left := Eval(node.Left, env)
 
 		if isError(left) {
@@ -74,6 +98,21 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 
+	case *ast.ThrowStatement:
+		return evalThrowStatement(node, env)
+
+	case *ast.DeferStatement:
+		return evalDeferStatement(node, env)
+
+	case *ast.StructStatement:
+		return evalStructStatement(node, env)
+
+	case *ast.EnumStatement:
+		return evalEnumStatement(node, env)
+
+	case *ast.TryExpression:
+		return evalTryExpression(node, env)
+
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
 
@@ -90,7 +129,32 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return val
 		}
 
-		env.Set(node.Name.Value, val)
+		// 同じスコープでconstとして束縛された名前は再宣言できない
+		if env.IsConst(node.Name.Value) {
+			return newError(nameErrorKind, "cannot redeclare constant %s", node.Name.Value)
+		}
+
+		if node.IsConst() {
+			env.SetConst(node.Name.Value, val)
+		} else {
+			env.Set(node.Name.Value, val)
+		}
+
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+
+	case *ast.ImportStatement:
+		module := DefaultLoader.Import(node.Path)
+
+		if isError(module) {
+			return module
+		}
+
+		env.Set(node.Name.Value, module)
+
+	case *ast.ExportStatement:
+		// 公開する名前は、モジュールローダがモジュールの評価後に集める
+		return Eval(node.Statement, env)
 
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
@@ -105,54 +169,65 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 
 	case *ast.CallExpression:
+		result, _ := evalChain(node, env)
+		return result
 
-		function := Eval(node.Function, env)
+	case *ast.StringLiteral:
+		return evalStringLiteral(node)
 
-		if isError(function) {
-			return function
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
 		}
 
-		args := evalExpressions(node.Arguments, env)
+		return object.NewArray(elements)
 
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
+	case *ast.IndexExpression:
+		result, _ := evalChain(node, env)
+		return result
 
-		return applyFunction(function, args)
+	case *ast.SliceExpression:
+		result, _ := evalChain(node, env)
+		return result
 
-	case *ast.StringLiteral:
-		return &object.String{
-			Value: node.Value,
-		}
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
 
-	case *ast.ArrayLiteral:
+	case *ast.SetLiteral:
+		return evalSetLiteral(node, env)
+
+	case *ast.BytesLiteral:
+		return &object.Bytes{Value: node.Value}
+
+	case *ast.TupleLiteral:
 		elements := evalExpressions(node.Elements, env)
 
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
 
-		return &object.Array{
-			Elements: elements,
-		}
+		return &object.Tuple{Elements: elements}
 
-	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
 
-		if isError(left) {
-			return left
-		}
+	case *ast.ListComprehension:
+		return evalListComprehension(node, env)
 
-		index := Eval(node.Index, env)
+	case *ast.HashComprehension:
+		return evalHashComprehension(node, env)
 
-		if isError(index) {
-			return index
-		}
+	case *ast.NullLiteral:
+		return NULL
 
-		return evalIndexExpression(left, index)
+	case *ast.PipeExpression:
+		return evalPipeExpression(node, env)
 
-	case *ast.HashLiteral:
-		return evalHashLiteral(node, env)
+	case *ast.MemberExpression:
+		result, _ := evalChain(node, env)
+		return result
 	}
 
 	return nil
@@ -180,10 +255,13 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	return result
 }
 
-func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+func evalBlockStatement(block *ast.BlockStatement, outer *object.Environment) object.Object {
 
 	var result object.Object
 
+	// ブロックの中で宣言した変数がブロックの外に漏れないように、新しいスコープで評価する
+	env := object.NewEnclosedEnvironment(outer)
+
 	for _, statement := range block.Statements {
 
 		result = Eval(statement, env)
@@ -200,6 +278,11 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 
 	}
 
+	// 空のブロックや、let文で終わるブロックの値は null とする
+	if result == nil {
+		return NULL
+	}
+
 	return result
 }
 
@@ -222,7 +305,7 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 	case "+":
 		return evalPlusPrefixOperatorExpression(right)
 	default:
-		return newError("unknown operator: %s %s", operator, right.Type())
+		return newError(typeErrorKind, "unknown operator: %s %s", operator, right.Type())
 	}
 
 }
@@ -245,12 +328,12 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 
 	if right.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: -%s", right.Type())
+		return newError(typeErrorKind, "unknown operator: -%s", right.Type())
 	}
 
 	value := right.(*object.Integer).Value
 
-	return &object.Integer{Value: -value}
+	return object.NewInteger(-value)
 }
 
 func evalPlusPrefixOperatorExpression(right object.Object) object.Object {
@@ -261,24 +344,33 @@ func evalPlusPrefixOperatorExpression(right object.Object) object.Object {
 
 	value := right.(*object.Integer).Value
 
-	return &object.Integer{Value: value}
+	return object.NewInteger(value)
 }
 
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 
 	switch {
+	case operator == "in":
+		return evalInExpression(left, right)
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(object.Equal(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
 	case left.Type() != right.Type():
-		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+		return newError(typeErrorKind, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
+	case left.Type() == object.BYTES_OBJ && right.Type() == object.BYTES_OBJ && operator == "+":
+		leftVal := left.(*object.Bytes).Value
+		rightVal := right.(*object.Bytes).Value
+
+		value := make([]byte, 0, len(leftVal)+len(rightVal))
+
+		return &object.Bytes{Value: append(append(value, leftVal...), rightVal...)}
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(typeErrorKind, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 
 }
@@ -290,13 +382,16 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftValue + rightValue}
+		return object.NewInteger(leftValue + rightValue)
 	case "-":
-		return &object.Integer{Value: leftValue - rightValue}
+		return object.NewInteger(leftValue - rightValue)
 	case "*":
-		return &object.Integer{Value: leftValue * rightValue}
+		return object.NewInteger(leftValue * rightValue)
 	case "/":
-		return &object.Integer{Value: leftValue / rightValue}
+		if rightValue == 0 {
+			return newError(zeroDivisionErrorKind, "division by zero")
+		}
+		return object.NewInteger(leftValue / rightValue)
 	case "<":
 		return nativeBoolToBooleanObject(leftValue < rightValue)
 	case ">":
@@ -306,7 +401,7 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case "!=":
 		return nativeBoolToBooleanObject(leftValue != rightValue)
 	default:
-		return newError("uknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(typeErrorKind, "uknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 
 }
@@ -344,8 +439,14 @@ func isTruthy(obj object.Object) bool {
 
 }
 
-func newError(format string, a ...interface{}) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
+/**
+ * 関数名: newError
+ * 処理: エラーの種類とメッセージを指定して、実行時エラーを生成する
+ * 引数: エラーの種類, エラーメッセージの書式, 書式に埋め込む値
+ * 戻値: エラー
+ */
+func newError(kind string, format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...), Kind: kind}
 }
 
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
@@ -358,7 +459,7 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return builtin
 	}
 
-	return newError("identifier not found: " + node.Value)
+	return newError(nameErrorKind, "identifier not found: %s", node.Value)
 }
 
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
@@ -384,22 +485,33 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 	switch function := fn.(type) {
 
 	case *object.Function:
+		if len(args) != len(function.Parameters) {
+			return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=%d", len(args), len(function.Parameters))
+		}
+
 		extendedEnv := extendFunctionEnv(function, args)
 		evaluated := Eval(function.Body, extendedEnv)
+		evaluated = runDeferredCalls(extendedEnv, evaluated)
 		return unwrapReturnValue(evaluated)
 
 	case *object.Builtin:
 		return function.Fn(args...)
 
+	case *object.StructType:
+		return newInstance(function, args)
+
+	case *object.VariantConstructor:
+		return newEnumValue(function, args)
+
 	default:
-		return newError("not a function: %+v", fn.Type())
+		return newError(typeErrorKind, "not a function: %+v", fn.Type())
 	}
 
 }
 
 func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
 
-	env := object.NewEnclosedEnvironment(fn.Env)
+	env := object.NewFrameEnvironment(fn.Env)
 
 	for paramsIdx, param := range fn.Parameters {
 		env.Set(param.Value, args[paramsIdx])
@@ -408,6 +520,62 @@ func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Enviro
 	return env
 }
 
+/**
+ * 関数名: evalDeferStatement
+ * 処理: defer文を評価する
+ *  関数と引数はこの時点で評価し、呼び出しは最も内側の関数を抜けるときに行う
+ * 引数: defer文, 環境
+ * 戻値: エラー : 登録できた場合は NULL
+ */
+func evalDeferStatement(ds *ast.DeferStatement, env *object.Environment) object.Object {
+
+	function, stopped := evalChain(ds.Call.Function, env)
+
+	if isError(function) {
+		return function
+	}
+
+	// defer x?.close() の x がnullであれば、何も登録しない
+	if stopped {
+		return NULL
+	}
+
+	args := evalExpressions(ds.Call.Arguments, env)
+
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+
+	if !env.Defer(object.DeferredCall{Function: function, Arguments: args}) {
+		return newError(typeErrorKind, "defer is only allowed inside a function")
+	}
+
+	return NULL
+}
+
+/**
+ * 関数名: runDeferredCalls
+ * 処理: 関数の環境に登録された呼び出しを、登録とは逆の順で実行する
+ *  関数の評価結果 ( 戻り値・エラー ) によらず、すべての呼び出しを実行する
+ *  呼び出しがエラーになった場合、関数の評価結果がエラーでなければそのエラーで置き換える
+ *  関数の評価結果が既にエラーであれば、元のエラーを優先する
+ * 引数: 関数の環境, 関数の評価結果
+ * 戻値: 評価結果
+ */
+func runDeferredCalls(env *object.Environment, result object.Object) object.Object {
+
+	for _, call := range env.TakeDeferred() {
+
+		evaluated := applyFunction(call.Function, call.Arguments)
+
+		if isError(evaluated) && !isError(result) {
+			result = evaluated
+		}
+	}
+
+	return result
+}
+
 func unwrapReturnValue(obj object.Object) object.Object {
 
 	if returnValue, ok := obj.(*object.ReturnValue); ok {
@@ -423,7 +591,7 @@ func evalStringInfixExpression(
 ) object.Object {
 
 	if operator != "+" {
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(typeErrorKind, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 
 	leftVal := left.(*object.String).Value
@@ -437,10 +605,16 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.TUPLE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalTupleIndexExpression(left, index)
+	case left.Type() == object.BYTES_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalBytesIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return newError(typeErrorKind, "index operator not supported: %s", left.Type())
 	}
 
 }
@@ -449,15 +623,304 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 
 	arrayObject := array.(*object.Array)
 
-	idx := index.(*object.Integer).Value
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, arrayObject.Len())
 
-	max := int64(len(arrayObject.Elements) - 1)
+	if !ok {
+		return NULL
+	}
 
-	if idx < 0 || idx > max {
+	return arrayObject.At(int(idx))
+}
+
+func evalTupleIndexExpression(tuple, index object.Object) object.Object {
+
+	tupleObject := tuple.(*object.Tuple)
+
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(tupleObject.Elements))
+
+	if !ok {
 		return NULL
 	}
 
-	return arrayObject.Elements[idx]
+	return tupleObject.Elements[idx]
+}
+
+func evalStringIndexExpression(str, index object.Object) object.Object {
+
+	// 添字はコードポイント単位で数える
+	runes := []rune(str.(*object.String).Value)
+
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(runes))
+
+	if !ok {
+		return NULL
+	}
+
+	return &object.String{Value: string(runes[idx])}
+}
+
+/**
+ * 関数名: normalizeIndex
+ * 処理: 負のインデックスを末尾からの位置に変換し、範囲内かどうかを判定する
+ * 引数: インデックス, 長さ
+ * 戻値: 変換後のインデックス, 範囲内かどうか
+ */
+func normalizeIndex(idx int64, length int) (int64, bool) {
+
+	if idx < 0 {
+		idx += int64(length)
+	}
+
+	if idx < 0 || idx >= int64(length) {
+		return 0, false
+	}
+
+	return idx, true
+}
+
+/**
+ * 関数名: evalChain
+ * 処理: 後置の式 ( メンバー参照・添字・スライス・呼び出し ) の連なりを評価する
+ *  ?. や ?[ の左辺がnullの場合は、それより後ろの連なりを評価せずに null とする
+ *  ( config?.db.host は、config がnullであれば .host も評価せずに null になる )
+ * 引数: 式, 環境
+ * 戻値: 評価結果, 連なりの評価を打ち切った場合 ( エラーを含む ) はtrue
+ */
+func evalChain(node ast.Expression, env *object.Environment) (object.Object, bool) {
+
+	switch node := node.(type) {
+
+	case *ast.MemberExpression:
+		obj, stopped := evalChainReceiver(node.Object, node.Optional, env)
+
+		if stopped {
+			return obj, true
+		}
+
+		return evalMemberExpression(obj, node.Property.Value), false
+
+	case *ast.IndexExpression:
+		left, stopped := evalChainReceiver(node.Left, node.Optional, env)
+
+		if stopped {
+			return left, true
+		}
+
+		index := Eval(node.Index, env)
+
+		if isError(index) {
+			return index, true
+		}
+
+		return evalIndexExpression(left, index), false
+
+	case *ast.SliceExpression:
+		left, stopped := evalChainReceiver(node.Left, node.Optional, env)
+
+		if stopped {
+			return left, true
+		}
+
+		return evalSliceExpression(node, left, env), false
+
+	case *ast.CallExpression:
+		function, stopped := evalChainReceiver(node.Function, false, env)
+
+		if stopped {
+			return function, true
+		}
+
+		args := evalExpressions(node.Arguments, env)
+
+		if len(args) == 1 && isError(args[0]) {
+			return args[0], true
+		}
+
+		return applyFunction(function, args), false
+	}
+
+	return Eval(node, env), false
+}
+
+// 後置の式の左辺を評価する
+// .. エラーの場合、左辺の連なりが打ち切られた場合、?. や ?[ の左辺がnullの場合はtrueを返す
+func evalChainReceiver(left ast.Expression, optional bool, env *object.Environment) (object.Object, bool) {
+
+	obj, stopped := evalChain(left, env)
+
+	if stopped || isError(obj) {
+		return obj, true
+	}
+
+	if optional && obj == NULL {
+		return NULL, true
+	}
+
+	return obj, false
+}
+
+/**
+ * 関数名: evalSliceExpression
+ * 処理: スライス式を評価する。配列・タプル・文字列・バイト列をスライスできる
+ * 引数: スライス式, 評価済みの左辺, 環境
+ * 戻値: 評価結果
+ */
+func evalSliceExpression(node *ast.SliceExpression, left object.Object, env *object.Environment) object.Object {
+
+	bounds := []object.Object{}
+
+	for _, exp := range []ast.Expression{node.Start, node.End, node.Step} {
+
+		// 省略した境界はnullとして扱う
+		if exp == nil {
+			bounds = append(bounds, NULL)
+			continue
+		}
+
+		bound := Eval(exp, env)
+
+		if isError(bound) {
+			return bound
+		}
+
+		if bound != NULL && bound.Type() != object.INTEGER_OBJ {
+			return newError(typeErrorKind, "slice indices must be INTEGER, got %s", bound.Type())
+		}
+
+		bounds = append(bounds, bound)
+	}
+
+	switch left := left.(type) {
+
+	case *object.Array:
+		indices, err := sliceIndices(bounds[0], bounds[1], bounds[2], left.Len())
+
+		if err != nil {
+			return err
+		}
+
+		elements := make([]object.Object, len(indices))
+
+		for i, idx := range indices {
+			elements[i] = left.At(int(idx))
+		}
+
+		return object.NewArray(elements)
+
+	case *object.Tuple:
+		indices, err := sliceIndices(bounds[0], bounds[1], bounds[2], len(left.Elements))
+
+		if err != nil {
+			return err
+		}
+
+		elements := make([]object.Object, len(indices))
+
+		for i, idx := range indices {
+			elements[i] = left.Elements[idx]
+		}
+
+		return &object.Tuple{Elements: elements}
+
+	case *object.String:
+		runes := []rune(left.Value)
+
+		indices, err := sliceIndices(bounds[0], bounds[1], bounds[2], len(runes))
+
+		if err != nil {
+			return err
+		}
+
+		out := make([]rune, len(indices))
+
+		for i, idx := range indices {
+			out[i] = runes[idx]
+		}
+
+		return &object.String{Value: string(out)}
+
+	case *object.Bytes:
+		indices, err := sliceIndices(bounds[0], bounds[1], bounds[2], len(left.Value))
+
+		if err != nil {
+			return err
+		}
+
+		out := make([]byte, len(indices))
+
+		for i, idx := range indices {
+			out[i] = left.Value[idx]
+		}
+
+		return &object.Bytes{Value: out}
+
+	default:
+		return newError(typeErrorKind, "slice operator not supported: %s", left.Type())
+	}
+}
+
+/**
+ * 関数名: sliceIndices
+ * 処理: スライスの境界から取り出すインデックスの一覧を求める
+ *  負の位置は末尾から数え、範囲外の位置は端に丸める
+ * 引数: 開始位置, 終了位置, 増分 ( 省略した場合はnull ), 長さ
+ * 戻値: インデックスの一覧, エラー
+ */
+func sliceIndices(start, end, step object.Object, length int) ([]int64, *object.Error) {
+
+	n := int64(length)
+	stepValue := int64(1)
+
+	if step != NULL {
+		stepValue = step.(*object.Integer).Value
+	}
+
+	if stepValue == 0 {
+		return nil, newError(valueErrorKind, "slice step cannot be zero")
+	}
+
+	// 増分が負の場合は、末尾から先頭に向かって取り出す
+	lower, upper := int64(0), n
+
+	if stepValue < 0 {
+		lower, upper = -1, n-1
+	}
+
+	clamp := func(bound object.Object, omitted int64) int64 {
+
+		if bound == NULL {
+			return omitted
+		}
+
+		idx := bound.(*object.Integer).Value
+
+		if idx < 0 {
+			idx += n
+			if idx < lower {
+				idx = lower
+			}
+		} else if idx > upper {
+			idx = upper
+		}
+
+		return idx
+	}
+
+	var from, to int64
+
+	if stepValue > 0 {
+		from, to = clamp(start, lower), clamp(end, upper)
+	} else {
+		from, to = clamp(start, upper), clamp(end, lower)
+	}
+
+	indices := []int64{}
+
+	for i := from; (stepValue > 0 && i < to) || (stepValue < 0 && i > to); i += stepValue {
+		indices = append(indices, i)
+	}
+
+	return indices, nil
 }
 
 func evalHashLiteral(
@@ -465,9 +928,12 @@ func evalHashLiteral(
 	env *object.Environment,
 ) object.Object {
 
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
+
+	// キーを記述した順に評価して追加する
+	for _, keyNode := range node.Keys {
 
-	for keyNode, valueNode := range node.Pairs {
+		valueNode := node.Pairs[keyNode]
 
 		key := Eval(keyNode, env)
 
@@ -475,10 +941,10 @@ func evalHashLiteral(
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.AsHashable(key)
 
 		if !ok {
-			return newError("unusable as hash key: %s", key.Type())
+			return newError(keyErrorKind, "unusable as hash key: %s", key.Type())
 		}
 
 		value := Eval(valueNode, env)
@@ -487,27 +953,193 @@ func evalHashLiteral(
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashKey, value)
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 
 	hashObject := hash.(*object.Hash)
 
-	key, ok := index.(object.Hashable)
+	key, ok := object.AsHashable(index)
 	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
+		return newError(keyErrorKind, "unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair, ok := hashObject.Get(key)
 	if !ok {
 		return NULL
 	}
 
 	return pair.Value
 }
+
+/**
+ * 関数名: evalAssignExpression
+ * 処理: 代入式を評価する。名前を束縛している最も内側の環境の値を書き換える
+ * 引数: 代入式, 環境
+ * 戻値: 代入した値
+ */
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+
+	val := Eval(node.Value, env)
+
+	if isError(val) {
+		return val
+	}
+
+	scope := env.Resolve(node.Name.Value)
+
+	if scope == nil {
+		return newError(nameErrorKind, "identifier not found: %s", node.Name.Value)
+	}
+
+	if scope.IsConst(node.Name.Value) {
+		return newError(nameErrorKind, "cannot assign to constant %s", node.Name.Value)
+	}
+
+	return scope.Set(node.Name.Value, val)
+}
+
+func evalNullishExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+
+	left := Eval(node.Left, env)
+
+	if isError(left) {
+		return left
+	}
+
+	if left != NULL {
+		return left
+	}
+
+	return Eval(node.Right, env)
+}
+
+/**
+ * 関数名: evalMemberExpression
+ * 処理: メンバアクセス式を評価する
+ *  ハッシュはキーの値を優先し、無ければメソッドを探す。キーもメソッドも無ければnullを返す
+ *  それ以外の組み込み型は、型ごとのメソッドのテーブルからメソッドを探す
+ * 引数: 左辺の値, メンバ名
+ * 戻値: 評価結果
+ */
+func evalMemberExpression(obj object.Object, name string) object.Object {
+
+	if module, ok := obj.(*object.Module); ok {
+
+		if val, ok := module.Exports[name]; ok {
+			return val
+		}
+
+		return newError(memberErrorKind, "module %s has no exported member %s", module.Name, name)
+	}
+
+	if instance, ok := obj.(*object.Instance); ok {
+		return evalInstanceMember(instance, name)
+	}
+
+	if et, ok := obj.(*object.EnumType); ok {
+		return evalEnumMember(et, name)
+	}
+
+	if ev, ok := obj.(*object.EnumValue); ok {
+		return evalEnumValueMember(ev, name)
+	}
+
+	if hash, ok := obj.(*object.Hash); ok {
+
+		if pair, ok := hash.Get(&object.String{Value: name}); ok {
+			return pair.Value
+		}
+
+		if method, ok := lookupMethod(hash, name); ok {
+			return method
+		}
+
+		return NULL
+	}
+
+	if _, ok := methods[obj.Type()]; !ok {
+		return newError(typeErrorKind, "member access not supported: %s", obj.Type())
+	}
+
+	if method, ok := lookupMethod(obj, name); ok {
+		return method
+	}
+
+	return newError(memberErrorKind, "unknown member: %s.%s", obj.Type(), name)
+}
+
+/**
+ * 関数名: evalPipeExpression
+ * 処理: パイプライン式を評価する
+ *  右辺が呼び出し式であれば、左辺の値を第1引数として追加して呼び出す
+ *  それ以外であれば、右辺を評価した関数を左辺の値だけを引数として呼び出す
+ * 引数: パイプライン式, 環境
+ * 戻値: 評価結果
+ */
+func evalPipeExpression(node *ast.PipeExpression, env *object.Environment) object.Object {
+
+	left := Eval(node.Left, env)
+
+	if isError(left) {
+		return left
+	}
+
+	var function object.Object
+	args := []object.Object{left}
+
+	if call, ok := node.Right.(*ast.CallExpression); ok {
+
+		var stopped bool
+		function, stopped = evalChain(call.Function, env)
+
+		if isError(function) {
+			return function
+		}
+
+		// x |> c?.f() の c がnullであれば、null とする
+		if stopped {
+			return NULL
+		}
+
+		rest := evalExpressions(call.Arguments, env)
+
+		if len(rest) == 1 && isError(rest[0]) {
+			return rest[0]
+		}
+
+		args = append(args, rest...)
+	} else {
+
+		var stopped bool
+		function, stopped = evalChain(node.Right, env)
+
+		if isError(function) {
+			return function
+		}
+
+		if stopped {
+			return NULL
+		}
+	}
+
+	if !isCallable(function) {
+		return newError(typeErrorKind, "pipeline target is not a function: %s", function.Type())
+	}
+
+	return applyFunction(function, args)
+}
+
+func isCallable(obj object.Object) bool {
+
+	switch obj.(type) {
+	case *object.Function, *object.Builtin, *object.StructType, *object.VariantConstructor:
+		return true
+	default:
+		return false
+	}
+}
@@ -6,9 +6,14 @@
 package evaluator
 
 import (
+	"fmt"
 	"github.com/MasaruFukazawa/monkey-lang/src/lexer"
 	"github.com/MasaruFukazawa/monkey-lang/src/object"
 	"github.com/MasaruFukazawa/monkey-lang/src/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -96,6 +101,23 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+		{`"a" == "a"`, true},
+		{`"a" != "a"`, false},
+		{`"a" == "b"`, false},
+		{`"a" + "b" == "ab"`, true},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1, 2] == [1, 3]", false},
+		{"[1, 2] != [1, 2, 3]", true},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`1 == "1"`, false},
+		{`[1] == {1: 1}`, false},
+		{"null == null", true},
+		{"let f = fn() { 1 }; f == f", true},
+		{"fn() { 1 } == fn() { 1 }", false},
+		{`[1, "a", [2]].contains([2])`, true},
+		{`[1, "a", [2]].contains("b")`, false},
 	}
 
 	for _, tt := range tests {
@@ -239,6 +261,8 @@ if (10 > 1) {
 		{"foobar", "identifier not found: foobar"},
 		{`"Hello" - "World"`, "unknown operator: STRING - STRING"},
 		{`{"name": "Monkey"}[fn(x) { x }];`, "unusable as hash key: FUNCTION"},
+		{`null["a"]`, "index operator not supported: NULL"},
+		{`1?.a`, "member access not supported: INTEGER"},
 	}
 
 	for _, tt := range tests {
@@ -399,6 +423,36 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+// 値を持たないブロックや関数の結果は null になり、組み込み関数に Go の nil が渡らない
+func TestNullResults(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(fn() { let x = 1 })()`, "null"},
+		{`(fn() {})()`, "null"},
+		{`let y = if (true) { let x = 1 }; [y]`, "[null]"},
+		{`puts((fn() { let x = 1 })())`, "null"},
+		{`[1].push((fn() {})())`, "[1, null]"},
+		{`len([(fn() { let x = 1 })()])`, "1"},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		if evaluated == nil {
+			t.Errorf("result of %q is Go nil", tt.input)
+			continue
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
 // 配列リテラルの評価テスト
 func TestArrayLiterals(t *testing.T) {
 
@@ -412,13 +466,13 @@ func TestArrayLiterals(t *testing.T) {
 		t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	if len(result.Elements) != 3 {
-		t.Fatalf("array has wrong num of elements. got=%d", len(result.Elements))
+	if result.Len() != 3 {
+		t.Fatalf("array has wrong num of elements. got=%d", result.Len())
 	}
 
-	testIntegerObject(t, result.Elements[0], 1)
-	testIntegerObject(t, result.Elements[1], 4)
-	testIntegerObject(t, result.Elements[2], 6)
+	testIntegerObject(t, result.At(0), 1)
+	testIntegerObject(t, result.At(1), 4)
+	testIntegerObject(t, result.At(2), 6)
 }
 
 // 配列の添字アクセスの評価テスト
@@ -465,6 +519,14 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
 			nil,
 		},
 	}
@@ -499,26 +561,29 @@ func TestHashLiterals(t *testing.T) {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
 	}
 
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", result.Len())
 	}
 
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+	for _, tt := range expected {
+		pair, ok := result.Get(tt.key)
 		if !ok {
 			t.Errorf("no pair for given key in Pairs")
 		}
 
-		testIntegerObject(t, pair.Value, expectedValue)
+		testIntegerObject(t, pair.Value, tt.value)
 	}
 
 }
@@ -569,3 +634,1375 @@ func TestHashIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+// match式の評価テスト
+func TestMatchExpressions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`match (1) { 1 => 10, _ => 20 }`, 10},
+		{`match (2) { 1 => 10, _ => 20 }`, 20},
+		{`match (-1) { -1 => 10, _ => 20 }`, 10},
+		{`match ("b") { "a" => 1, "b" => 2, _ => 3 }`, 2},
+		{`match (true) { false => 1, true => 2 }`, 2},
+		{`match (5) { x => x * 2 }`, 10},
+		{`match (5) { x if x > 10 => 1, x if x > 3 => 2, _ => 3 }`, 2},
+		{`match ([1, 2, 3]) { [a, b] => 1, [a, b, c] => a + b + c }`, 6},
+		{`match ([1, 2, 3]) { [first, ...rest] => len(rest) }`, 2},
+		{`match ([]) { [first, ...rest] => 1, [] => 2 }`, 2},
+		{`match ([1, [2, 3]]) { [a, [b, c]] => a + b + c }`, 6},
+		{`match ({"type": "ok", "value": 7}) { {"type": "err"} => 0, {"type": "ok", "value": v} => v }`, 7},
+		{`match ({"a": 1}) { {"b": x} => x, _ => 9 }`, 9},
+		{`match (3) { [x] => x, "3" => 1, _ => 2 }`, 2},
+		{`let x = 1; match (5) { x => x }; x`, 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+// match式のエラーのテスト
+func TestMatchExpressionErrors(t *testing.T) {
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`match (3) { 1 => 1, 2 => 2 }`, "match is not exhaustive: no arm matched 3"},
+		{`match (3) { x if x > 5 => 1 }`, "match is not exhaustive: no arm matched 3"},
+		{`match (3) { x if y => 1 }`, "identifier not found: y"},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+// nullリテラル、NULL合体演算子、オプショナルアクセスの評価テスト
+func TestNullAndOptionalAccess(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`null`, nil},
+		{`null ?? 5`, 5},
+		{`1 ?? 5`, 1},
+		{`false ?? 5`, false},
+		{`null ?? null`, nil},
+		{`let h = {"a": 1}; h["b"] ?? 2`, 2},
+		{`let config = {"db": {"port": 5432}}; config?["db"]?["port"] ?? 1`, 5432},
+		{`let config = {}; config?["db"]?["port"] ?? 1`, 1},
+		{`let config = {"db": {"port": 5432}}; config?.db?.port`, 5432},
+		{`let config = null; config?.db?.port ?? 3`, 3},
+		{`null?[undefined]`, nil},
+		{`let c = null; c?.upper()`, nil},
+		{`let c = null; c?.db.port ?? 3`, 3},
+		{`let c = null; c?.db.host`, nil},
+		{`let c = null; c?["db"]["host"]`, nil},
+		{`let c = null; c?.items[1:2].len()`, nil},
+		{`let c = null; (c?.db.port ?? 8080) + 1`, 8081},
+		{`let c = {"db": {"port": 1}}; c?.db.port + 1`, 2},
+		{`let c = {"db": null}; c?.db?.port`, nil},
+		{`let c = null; 1 |> c?.add(2)`, nil},
+		{`match (null) { null => 1, _ => 2 }`, 1},
+		{`null == null`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+// パイプライン演算子の評価テスト
+func TestPipeExpressions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let double = fn(x) { x * 2 }; 5 |> double()`, 10},
+		{`let double = fn(x) { x * 2 }; 5 |> double`, 10},
+		{`let sub = fn(a, b) { a - b }; 10 |> sub(3)`, 7},
+		{`let add = fn(a, b) { a + b }; let double = fn(x) { x * 2 }; 1 |> add(2) |> double()`, 6},
+		{`[1, 2, 3] |> len()`, 3},
+		{`[1, 2] |> push(3) |> len`, 3},
+		{`1 |> fn(x) { x + 1 }`, 2},
+		{`let sub = fn(a, b) { a - b }; 2 + 3 |> sub(1)`, 4},
+		{`"abc" |> 1`, "pipeline target is not a function: INTEGER"},
+		{`1 |> "f"()`, "pipeline target is not a function: STRING"},
+		{`1 |> f()`, "identifier not found: f"},
+		{`let add = fn(a, b) { a + b }; 1 |> add()`, "wrong number of arguments. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+// ドット記法によるメンバアクセスとメソッド呼び出しの評価テスト
+func TestMemberExpressions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let h = {"a": 1}; h.a`, 1},
+		{`let h = {"a": {"b": 2}}; h.a.b`, 2},
+		{`let h = {"a": 1}; h.b`, nil},
+		{`let h = {"f": fn(x) { x * 3 }}; h.f(2)`, 6},
+		{`{"a": 1, "b": 2}.len()`, 2},
+		{`{"len": 7}.len`, 7},
+		{`{"a": 1}.has("a")`, true},
+		{`{"a": 1}.has("b")`, false},
+		{`{"a": 1}.get("b", 5)`, 5},
+		{`{"a": 1}.keys()`, []string{"a"}},
+		{`{"a": 1}.values().first()`, 1},
+		{`"abc".upper()`, "ABC"},
+		{`"ABC".lower()`, "abc"},
+		{`"  abc ".trim()`, "abc"},
+		{`"abc".len()`, 3},
+		{`"a,b,c".split(",")`, []string{"a", "b", "c"}},
+		{`"monkey".contains("key")`, true},
+		{`"monkey".starts_with("mon")`, true},
+		{`"monkey".ends_with("mon")`, false},
+		{`[1, 2, 3].len()`, 3},
+		{`[1, 2, 3].first()`, 1},
+		{`[1, 2, 3].last()`, 3},
+		{`[1, 2, 3].rest().len()`, 2},
+		{`[].first()`, nil},
+		{`let xs = [1, 2]; xs.push(3).last()`, 3},
+		{`let xs = [1, 2]; xs.push(3); xs.len()`, 2},
+		{`[1, 2, 3].contains(2)`, true},
+		{`["a", "b"].contains("c")`, false},
+		{`[1, 2, 3].join("-")`, "1-2-3"},
+		{`["a", "b"].join(", ")`, "a, b"},
+		{`[1, 2, 3] |> len()`, 3},
+		{`let upper = "abc".upper; upper()`, "ABC"},
+		{`let s = "abc"; s?.upper()`, "ABC"},
+		{`let s = null; s?.upper()`, nil},
+		{`let s = null; s?.trim().upper().len()`, nil},
+		{`let h = {"s": null}; h.s?.upper() ?? "none"`, "none"},
+		{`let h = {"s": null}; h.s.upper()`, "member access not supported: NULL"},
+		{`1.foo`, "member access not supported: INTEGER"},
+		{`"abc".foo()`, "unknown member: STRING.foo"},
+		{`"abc".upper(1)`, "wrong number of arguments. got=1, want=0"},
+		{`"a,b".split(1)`, "argument to `split` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case []string:
+			array, ok := evaluated.(*object.Array)
+
+			if !ok {
+				t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if array.Len() != len(expected) {
+				t.Errorf("wrong num of elements. want=%d, got=%d", len(expected), array.Len())
+				continue
+			}
+
+			for i, el := range expected {
+				testStringObject(t, array.At(i), el)
+			}
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+				}
+				continue
+			}
+
+			testStringObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func testStringObject(t *testing.T, obj object.Object, expected string) bool {
+
+	str, ok := obj.(*object.String)
+
+	if !ok {
+		t.Errorf("object is not String. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if str.Value != expected {
+		t.Errorf("object has wrong value. got=%q, want=%q", str.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+// スライスと文字列の添字アクセスの評価テスト
+func TestSliceAndStringIndexExpressions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, 2, 3, 4, 5][1:3]`, []int64{2, 3}},
+		{`[1, 2, 3, 4, 5][:2]`, []int64{1, 2}},
+		{`[1, 2, 3, 4, 5][3:]`, []int64{4, 5}},
+		{`[1, 2, 3, 4, 5][:]`, []int64{1, 2, 3, 4, 5}},
+		{`[1, 2, 3, 4, 5][-2:]`, []int64{4, 5}},
+		{`[1, 2, 3, 4, 5][:-3]`, []int64{1, 2}},
+		{`[1, 2, 3, 4, 5][::2]`, []int64{1, 3, 5}},
+		{`[1, 2, 3, 4, 5][::-1]`, []int64{5, 4, 3, 2, 1}},
+		{`[1, 2, 3, 4, 5][3:0:-1]`, []int64{4, 3, 2}},
+		{`[1, 2, 3, 4, 5][-1:-4:-2]`, []int64{5, 3}},
+		{`[1, 2, 3][5:10]`, []int64{}},
+		{`[1, 2, 3][-10:10]`, []int64{1, 2, 3}},
+		{`[1, 2, 3][2:1]`, []int64{}},
+		{`let i = 1; [1, 2, 3][i:i + 1]`, []int64{2}},
+		{`"hello"[0]`, "h"},
+		{`"hello"[-1]`, "o"},
+		{`"hello"[1:4]`, "ell"},
+		{`"hello"[::-1]`, "olleh"},
+		{`"hello"[5]`, nil},
+		{`null?[1:2]`, nil},
+		{`[1, 2, 3][::0]`, errorMessage("slice step cannot be zero")},
+		{`[1, 2, 3]["a":]`, errorMessage("slice indices must be INTEGER, got STRING")},
+		{`1[1:2]`, errorMessage("slice operator not supported: INTEGER")},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+
+			if !ok {
+				t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if array.Len() != len(expected) {
+				t.Errorf("wrong num of elements for %q. want=%d, got=%d", tt.input, len(expected), array.Len())
+				continue
+			}
+
+			for i, el := range expected {
+				testIntegerObject(t, array.At(i), el)
+			}
+		case string:
+			testStringObject(t, evaluated, expected)
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+// テストケースで期待するエラーメッセージ
+type errorMessage string
+
+func testErrorObject(t *testing.T, obj object.Object, expected string) bool {
+
+	errObj, ok := obj.(*object.Error)
+
+	if !ok {
+		t.Errorf("object is not Error. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+		return false
+	}
+
+	return true
+}
+
+// const文、代入式、ブロックスコープの評価テスト
+func TestConstAssignmentAndBlockScope(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`const x = 5; x`, 5},
+		{`let x = 1; x = 2; x`, 2},
+		{`let x = 1; x = x + 1`, 2},
+		{`let x = 1; let y = 1; x = y = 3; x + y`, 6},
+		{`let x = 1; if (true) { let x = 2; }; x`, 1},
+		{`let x = 1; if (true) { x = 2; }; x`, 2},
+		{`if (true) { let y = 2; }; y`, errorMessage("identifier not found: y")},
+		{`let x = 1; if (true) { let x = 2; x = 3; }; x`, 1},
+		{`const x = 1; if (true) { let x = 2; x }`, 2},
+		{`let count = 0; let inc = fn() { count = count + 1 }; inc(); inc(); count`, 2},
+		{`let f = fn() { let x = 1; if (true) { let x = 2; }; x }; f()`, 1},
+		{`x = 1`, errorMessage("identifier not found: x")},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+}
+
+// パーサーでは判定できないconstへの再代入が実行時にエラーになることをテストする
+func TestConstRuntimeErrors(t *testing.T) {
+
+	tests := []struct {
+		first  string
+		second string
+		err    string
+	}{
+		{"const x = 1;", "x = 2;", "cannot assign to constant x"},
+		{"const x = 1;", "let x = 2;", "cannot redeclare constant x"},
+		{"const x = 1;", "let f = fn() { x = 2 }; f();", "cannot assign to constant x"},
+	}
+
+	for _, tt := range tests {
+
+		// REPLのように、同じ環境で別々に構文解析したプログラムを評価する
+		env := object.NewEnvironment()
+
+		for _, input := range []string{tt.first, tt.second} {
+
+			p := parser.New(lexer.New(input))
+			program := p.ParseProgram()
+
+			if len(p.Errors()) != 0 {
+				t.Fatalf("parser errors for %q: %v", input, p.Errors())
+			}
+
+			evaluated := Eval(program, env)
+
+			if input == tt.second {
+				testErrorObject(t, evaluated, tt.err)
+			}
+		}
+	}
+}
+
+// アロー関数の評価テスト
+func TestArrowFunctions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let double = x => x * 2; double(5)", 10},
+		{"let add = (a, b) => a + b; add(2, 3)", 5},
+		{"let one = () => 1; one()", 1},
+		{"let f = (a) => { let b = a * 2; return b + 1; 0 }; f(3)", 7},
+		{"let adder = x => y => x + y; adder(1)(2)", 3},
+		{"let apply = fn(f, x) { f(x) }; apply(x => x * x, 4)", 16},
+		{"let x = 10; let f = y => x + y; f(1)", 11},
+		{"5 |> (x => x + 1)", 6},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+
+	evaluated := testEval("x => x")
+
+	if _, ok := evaluated.(*object.Function); !ok {
+		t.Errorf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+	}
+}
+
+// 内包表記の評価テスト
+func TestComprehensions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[x * 2 for x in [1, 2, 3]]`, []int64{2, 4, 6}},
+		{`[x for x in [1, 2, 3, 4] if x > 2]`, []int64{3, 4}},
+		{`[x for x in [1, 2, 3, 4, 5, 6] if x > 1 if x < 5]`, []int64{2, 3, 4}},
+		{`[x * 10 + y for x in [1, 2] for y in [1, 2, 3] if x != y]`, []int64{12, 13, 21, 23}},
+		{`[y for x in [[1, 2], [3]] for y in x]`, []int64{1, 2, 3}},
+		{`[i * v for i, v in [5, 6, 7]]`, []int64{0, 6, 14}},
+		{`[x for x in []]`, []int64{}},
+		{`len([c for c in "abc"])`, 3},
+		{`[c for c in "abc"][1].upper() |> len()`, 1},
+		{`[v for k, v in {"a": 1}]`, []int64{1}},
+		{`let h = {k: v * 2 for k, v in {"a": 1, "b": 2}}; h["a"] + h["b"]`, 6},
+		{`let h = {x: x * x for x in [1, 2, 3] if x != 2}; [h[1], h[3], len(h.keys())]`, []int64{1, 9, 2}},
+		{`[x for x in [1, 2]]; x`, errorMessage("identifier not found: x")},
+		{`let x = 10; [x for x in [1]]; x`, 10},
+		{`[x for x in [0, 1] if x > 0 if y]`, errorMessage("identifier not found: y")},
+		{`[x for x in [1, 2] if x > 5 if undefined]`, []int64{}},
+		{`[undefined for x in [1, 2] if x > 5]`, []int64{}},
+		{`[x for x in 5]`, errorMessage("not iterable: INTEGER")},
+		{`[a for a, b, c in [1]]`, errorMessage("too many iteration variables: got=3, want=1 or 2")},
+		{`{[x]: x for x in [1]}`, errorMessage("unusable as hash key: ARRAY")},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+
+			if !ok {
+				t.Errorf("object is not Array for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+
+			if array.Len() != len(expected) {
+				t.Errorf("wrong num of elements for %q. want=%d, got=%d", tt.input, len(expected), array.Len())
+				continue
+			}
+
+			for i, el := range expected {
+				testIntegerObject(t, array.At(i), el)
+			}
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+}
+
+// モジュールのテスト用に、ディレクトリにファイルを作成する
+func writeModuleFiles(t *testing.T, dir string, files map[string]string) {
+
+	for name, source := range files {
+
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// import文とexport文の評価テスト
+func TestModules(t *testing.T) {
+
+	dir := t.TempDir()
+	libDir := t.TempDir()
+
+	writeModuleFiles(t, dir, map[string]string{
+		"lib/math.monkey": `
+export let square = fn(x) { x * x };
+export const answer = 42;
+export struct Vec { x fn twice() { self.x * 2 } }
+let hidden = 1;
+`,
+		"lib/counter.monkey": `
+import "./math.monkey" as m;
+let count = 0;
+export let next = fn() { count = count + 1 };
+export let area = fn(r) { m.square(r) * 3 };
+`,
+		"main.monkey": `
+import "lib/math.monkey" as m;
+import "lib/counter.monkey";
+import "shared.monkey";
+counter.next();
+counter.next();
+m.square(counter.next()) + counter.area(2) + shared.base
+`,
+	})
+
+	writeModuleFiles(t, libDir, map[string]string{
+		"shared.monkey": `export let base = 100;`,
+	})
+
+	loader := DefaultLoader
+	defer func() { DefaultLoader = loader }()
+
+	DefaultLoader = NewModuleLoader(libDir)
+
+	// counter.monkey は一度だけ評価されるので、next() の呼び出しの状態が共有される
+	result := DefaultLoader.Run(filepath.Join(dir, "main.monkey"), object.NewEnvironment())
+	testIntegerObject(t, result, 9+12+100)
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`import "lib/math.monkey" as m; m.answer`, 42},
+		{`import "lib/math.monkey" as m; m.Vec(21).twice()`, 42},
+		{`import "lib/math.monkey" as m; m.hidden`, errorMessage("module math has no exported member hidden")},
+		{`import "lib/missing.monkey" as m;`, errorMessage(`module not found: "lib/missing.monkey"`)},
+		{`import "./shared.monkey";`, errorMessage(`module not found: "./shared.monkey"`)},
+	}
+
+	for _, tt := range tests {
+
+		source := filepath.Join(dir, "test.monkey")
+		writeModuleFiles(t, dir, map[string]string{"test.monkey": tt.input})
+
+		evaluated := DefaultLoader.Run(source, object.NewEnvironment())
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+}
+
+// 循環したimportの検出のテスト
+func TestModuleImportCycle(t *testing.T) {
+
+	dir := t.TempDir()
+
+	writeModuleFiles(t, dir, map[string]string{
+		"a.monkey":    `import "b.monkey"; export let x = 1;`,
+		"b.monkey":    `import "c.monkey"; export let y = 1;`,
+		"c.monkey":    `import "a.monkey"; export let z = 1;`,
+		"main.monkey": `import "a.monkey";`,
+		"bad.monkey":  `let = 1;`,
+		"self.monkey": `import "bad.monkey";`,
+	})
+
+	loader := DefaultLoader
+	defer func() { DefaultLoader = loader }()
+
+	DefaultLoader = NewModuleLoader()
+
+	a := filepath.Join(dir, "a.monkey")
+	b := filepath.Join(dir, "b.monkey")
+	c := filepath.Join(dir, "c.monkey")
+
+	evaluated := DefaultLoader.Run(filepath.Join(dir, "main.monkey"), object.NewEnvironment())
+	testErrorObject(t, evaluated, fmt.Sprintf("import cycle detected: %s -> %s -> %s -> %s", a, b, c, a))
+
+	evaluated = DefaultLoader.Run(filepath.Join(dir, "self.monkey"), object.NewEnvironment())
+	testErrorObject(t, evaluated, fmt.Sprintf(
+		"parse errors in %s: expected next token to be IDENT, got = instead; no prefix parse function for = found",
+		filepath.Join(dir, "bad.monkey"),
+	))
+}
+
+// throw文とtry式の評価テスト
+func TestExceptions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`try { 1 } catch (e) { 2 }`, 1},
+		{`try { throw "boom"; 1 } catch (e) { e.message }`, "boom"},
+		{`try { throw "boom" } catch (e) { e.kind }`, "Error"},
+		{`try { throw 42 } catch (e) { e.value }`, 42},
+		{`try { 1 / 0 } catch (e) { e.message }`, "division by zero"},
+		{`try { 1 / 0 } catch (e) { e.kind }`, "ZeroDivisionError"},
+		{`try { x } catch (e) { e.kind }`, "NameError"},
+		{`try { len(1) } catch (e) { e.kind }`, "ArgumentError"},
+		{`try { 1 + true } catch (e) { e.kind }`, "TypeError"},
+		{`try { {}[fn(x) { x }] } catch (e) { e.kind }`, "KeyError"},
+		{`try { throw "boom" } catch { 7 }`, 7},
+		{"let x = 1;\ntry {\n  x + true\n} catch (e) { [e.line, e.column] }", []int64{3, 5}},
+		{`let f = fn() { throw "inner" }; try { f() } catch (e) { e.line }`, 1},
+		{`let r = []; try { throw "a" } catch (e) { r = r.push(1) } finally { r = r.push(2) }; r`, []int64{1, 2}},
+		{`let r = 0; try { 1 } finally { r = 5 }; r`, 5},
+		{`try { 1 } finally { 2 }`, 1},
+		{`let f = fn() { try { return 1 } finally { return 2 } }; f()`, 2},
+		{`let f = fn() { try { return 1 } finally { 3 } }; f()`, 1},
+		{`try { try { 1 / 0 } catch (e) { throw e } } catch (e) { e.kind }`, "ZeroDivisionError"},
+		{`try { try { throw "a" } finally { 1 } } catch (e) { e.message }`, "a"},
+		{`try { throw {"message": "m"} } catch (e) { e.value["message"] }`, "m"},
+		{`try { throw {"message": "m", "kind": "K", "code": 42} } catch (e) { e.value["code"] }`, 42},
+		{`try { throw {"message": "m", "kind": "K"} } catch (e) { e.kind }`, "Error"},
+		{`try { try { 1 / 0 } catch (e) { throw e.set("note", 1) } } catch (e) { e.value["kind"] }`, "ZeroDivisionError"},
+		{`try { try { 1 / 0 } catch (e) { throw e.set("note", 1) } } catch (e) { e.kind }`, "Error"},
+		{"try {\n  try { 1 / 0 } catch (e) { throw e }\n} catch (e) { e.line }", 2},
+		{`throw "uncaught"; 1`, errorMessage("uncaught")},
+		{`try { 1 / 0 } catch (e) { e.nope.x }`, errorMessage("member access not supported: NULL")},
+		{`try { 1 } finally { throw "f" }`, errorMessage("f")},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testStringObject(t, evaluated, expected)
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+
+			if !ok {
+				t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if array.Len() != len(expected) {
+				t.Errorf("wrong num of elements. want=%d, got=%d", len(expected), array.Len())
+				continue
+			}
+
+			for i, want := range expected {
+				testIntegerObject(t, array.At(i), want)
+			}
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+}
+
+// 捕捉されなかったエラーが種類と位置を持つことをテストする
+func TestErrorKindAndPosition(t *testing.T) {
+
+	evaluated := testEval("let a = 1;\nlet b = a + true;")
+
+	err, ok := evaluated.(*object.Error)
+
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if err.Kind != "TypeError" {
+		t.Errorf("wrong error kind. expected=%q, got=%q", "TypeError", err.Kind)
+	}
+
+	if err.Position.String() != "2:11" {
+		t.Errorf("wrong error position. expected=%q, got=%q", "2:11", err.Position.String())
+	}
+}
+
+// 実行時エラーの種類のテスト
+func TestErrorKinds(t *testing.T) {
+
+	tests := []struct {
+		input        string
+		expectedKind string
+	}{
+		{`1 + true`, "TypeError"},
+		{`len(1, 2)`, "ArgumentError"},
+		{`foobar`, "NameError"},
+		{`{[1]: 2}`, "KeyError"},
+		{`enum Color { Red }; Color.Blue`, "MemberError"},
+		{`1 / 0`, "ZeroDivisionError"},
+		{`from_hex("zz")`, "ValueError"},
+		{`read_file("/nonexistent/monkey")`, "IOError"},
+		{`match (1) { 2 => 3 }`, "MatchError"},
+		{`throw "oops"`, "Error"},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		err, ok := evaluated.(*object.Error)
+
+		if !ok {
+			t.Errorf("object is not Error. input=%q, got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if err.Kind != tt.expectedKind {
+			t.Errorf("wrong error kind. input=%q, expected=%q, got=%q (%s)", tt.input, tt.expectedKind, err.Kind, err.Message)
+		}
+	}
+}
+
+// defer文の評価テスト
+func TestDeferStatements(t *testing.T) {
+
+	prelude := `let log = []; let record = fn(x) { log = log.push(x) };`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let f = fn() { defer record(1); defer record(2); record(0) }; f(); log`, []int64{0, 2, 1}},
+		{`let f = fn() { defer record(1); if (true) { return 5 }; record(2) }; let r = f(); [r, len(log), log[0]]`, []int64{5, 1, 1}},
+		{`let f = fn() { defer record(1); 1 / 0; record(2) }; try { f() } catch (e) { record(3) }; log`, []int64{1, 3}},
+		{`let f = fn() { let x = 1; defer record(x); x = 2; record(x) }; f(); log`, []int64{2, 1}},
+		{`let f = fn() { if (true) { defer record(1) }; record(2) }; f(); log`, []int64{2, 1}},
+		{`let f = fn(n) { defer record(n); if (n > 0) { f(n - 1) } }; f(2); log`, []int64{0, 1, 2}},
+		{`let f = fn() { defer record(1); 7 }; f()`, 7},
+		{`let c = null; let f = fn() { defer c?.close(); 7 }; f()`, 7},
+		{`let f = fn() { record(0); defer record(1) }; [f(), log]`, "[null, [0, 1]]"},
+		{`let f = fn() { defer record(1); }; puts(f()); log`, []int64{1}},
+		{`let f = fn() { defer fn() { throw "cleanup" }(); 7 }; f()`, errorMessage("cleanup")},
+		{`let f = fn() { defer fn() { throw "cleanup" }(); throw "body" }; f()`, errorMessage("body")},
+		{`let f = fn() { defer fn() { throw "a" }(); defer record(1) }; try { f() } catch (e) { log }`, []int64{1}},
+		{`defer record(1);`, errorMessage("defer is only allowed inside a function")},
+		{`let f = fn() { defer nope(1) }; f()`, errorMessage("identifier not found: nope")},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(prelude + tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+
+			if !ok {
+				t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if array.Len() != len(expected) {
+				t.Errorf("wrong num of elements for %q. want=%d, got=%d", tt.input, len(expected), array.Len())
+				continue
+			}
+
+			for i, want := range expected {
+				testIntegerObject(t, array.At(i), want)
+			}
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+}
+
+// 構造体の評価テスト
+func TestStructs(t *testing.T) {
+
+	prelude := `
+struct Point {
+	x, y
+	fn norm() { self.x * self.x + self.y * self.y }
+	fn add(other) { Point(self.x + other.x, self.y + other.y) }
+	fn scale(k) { Point(self.x * k, self.y * k).norm() }
+}
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Point(1, 2).x`, 1},
+		{`let p = Point(3, 4); p.y`, 4},
+		{`Point(3, 4).norm()`, 25},
+		{`Point(1, 2).add(Point(3, 4)).y`, 6},
+		{`Point(1, 1).scale(2)`, 8},
+		{`let f = Point(3, 4).norm; f()`, 25},
+		{`[[1, 2], [3, 4]] |> fn(ps) { [Point(p[0], p[1]).norm() for p in ps] } |> fn(ns) { ns[1] }`, 25},
+		{`struct Empty {}; Empty().x`, errorMessage("unknown member: Empty.x")},
+		{`Point(1, 2).z`, errorMessage("unknown member: Point.z")},
+		{`Point(1)`, errorMessage("wrong number of arguments. got=1, want=2")},
+		{`Point(1, 2).norm(1)`, errorMessage("wrong number of arguments. got=1, want=0")},
+		{`Point(1, 2) + 1`, errorMessage("type mismatch: INSTANCE + INTEGER")},
+		{`const P = 1; struct P { a }`, errorMessage("cannot redeclare constant P")},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(prelude + tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+
+	inspects := []struct {
+		input    string
+		expected string
+	}{
+		{`Point(1, 2)`, "Point{x: 1, y: 2}"},
+		{`Point("a", [1, Point(0, 0)])`, `Point{x: "a", y: [1, Point{x: 0, y: 0}]}`},
+		{`Point`, "<struct Point>"},
+	}
+
+	for _, tt := range inspects {
+
+		evaluated := testEval(prelude + tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+// 列挙型の評価テスト
+func TestEnums(t *testing.T) {
+
+	prelude := `
+enum Status { Pending, Done(result), Failed(reason) }
+enum Pair { Of(a, b) }
+let describe = fn(s) {
+	match (s) {
+		Status.Pending => "pending",
+		Status.Done(r) if r > 10 => "big",
+		Status.Done(r) => "done",
+		Status.Failed => "failed",
+	}
+};
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`describe(Status.Pending)`, "pending"},
+		{`describe(Status.Done(1))`, "done"},
+		{`describe(Status.Done(42))`, "big"},
+		{`describe(Status.Failed("boom"))`, "failed"},
+		{`match (Pair.Of(1, [2, 3])) { Pair.Of(a, [b, c]) => a + b + c }`, 6},
+		{`Status.Done(7).result`, 7},
+		{`let done = Status.Done; done(8).result`, 8},
+		{`(9 |> Status.Done).result`, 9},
+		{`Status.Pending == Status.Pending`, true},
+		{`Status.Done(1) == Status.Done(1)`, true},
+		{`Status.Done("a") == Status.Done("a")`, true},
+		{`Status.Done(1) != Status.Done(2)`, true},
+		{`Status.Done(1) == Status.Failed(1)`, false},
+		{`Pair.Of(Status.Pending, 1) == Pair.Of(Status.Pending, 1)`, true},
+		{`let h = {Status.Done(1): "one", Status.Pending: "p"}; h[Status.Done(1)]`, "one"},
+		{`let h = {Status.Done(1): "one", Status.Pending: "p"}; h[Status.Pending]`, "p"},
+		{`{Status.Done([1]): 1}`, errorMessage("unusable as hash key: ENUM_VALUE")},
+		{`Status.Nope`, errorMessage("unknown variant: Status.Nope")},
+		{`Status.Done(1, 2)`, errorMessage("wrong number of arguments. got=2, want=1")},
+		{`Status.Done(1).reason`, errorMessage("unknown member: Status.Done.reason")},
+		{`Status.Pending < Status.Pending`, errorMessage("unknown operator: ENUM_VALUE < ENUM_VALUE")},
+		{`match (1) { Status.Done(a, b) => a }`, errorMessage("wrong number of payload patterns for Status.Done: got=2, want=1")},
+		{`match (1) { Status.Other => 1 }`, errorMessage("unknown variant: Status.Other")},
+		{`match (1) { describe.Other => 1 }`, errorMessage("not an enum: describe")},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(prelude + tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+
+	inspects := []struct {
+		input    string
+		expected string
+	}{
+		{`Status.Pending`, "Status.Pending"},
+		{`Status.Done([1, 2])`, "Status.Done([1, 2])"},
+		{`Pair.Of(1, Status.Pending)`, "Pair.Of(1, Status.Pending)"},
+		{`Status`, "<enum Status>"},
+		{`Status.Done`, "<variant Status.Done>"},
+		{`[Status.Pending, Pair.Of]`, "[Status.Pending, <variant Pair.Of>]"},
+	}
+
+	for _, tt := range inspects {
+
+		evaluated := testEval(prelude + tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+// ハッシュが追加した順を保つことをテストする
+func TestHashInsertionOrder(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"c": 1, "a": 2, "b": 3}`, `{"c": 1, "a": 2, "b": 3}`},
+		{`{3: "c", 1: "a", true: "t", 2: "b"}`, `{3: "c", 1: "a", true: "t", 2: "b"}`},
+		{`{"z": 1, "y": 2, "x": 3}.keys()`, `["z", "y", "x"]`},
+		{`{"z": 1, "y": 2, "x": 3}.values()`, "[1, 2, 3]"},
+		{`{"b": 1, "a": 2, "b": 3}`, `{"b": 3, "a": 2}`},
+		{`[k for k in {"q": 1, "p": 2, "r": 3}]`, `["q", "p", "r"]`},
+		{`[v * 10 for k, v in {"q": 1, "p": 2, "r": 3}]`, "[10, 20, 30]"},
+		{`{x: x * x for x in [5, 3, 9, 1]}`, "{5: 25, 3: 9, 9: 81, 1: 1}"},
+		{`let log = []; let f = fn(x) { log = log.push(x); x }; {f("b"): f(1), f("a"): f(2)}; log`, `["b", 1, "a", 2]`},
+	}
+
+	for _, tt := range tests {
+
+		// Go の map の走査順に依存しないことを確かめるため、何度か評価する
+		for i := 0; i < 10; i++ {
+
+			evaluated := testEval(tt.input)
+
+			if evaluated.Inspect() != tt.expected {
+				t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+				break
+			}
+		}
+	}
+}
+
+func TestPersistentCollections(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let a = [1, 2, 3]; let b = a.push(4); [a, b]`, "[[1, 2, 3], [1, 2, 3, 4]]"},
+		{`let a = [1, 2, 3]; let b = rest(a); [a, b, rest(b).push(9)]`, "[[1, 2, 3], [2, 3], [3, 9]]"},
+		{`rest([])`, "null"},
+		{`let a = [1, 2, 3]; let b = a.set(0, 9); [a, b, a.set(-1, 0)]`, "[[1, 2, 3], [9, 2, 3], [1, 2, 0]]"},
+		{`[1, 2].set(2, 0)`, "ERROR: index out of range: 2"},
+		{`let f = fn(arr, n) { if (n == 0) { arr } else { f(arr.push(n), n - 1) } }; f([], 2000).len()`, "2000"},
+		{`let h = {"a": 1, "b": 2}; let g = h.set("a", 9).set("c", 3); [h, g]`, `[{"a": 1, "b": 2}, {"a": 9, "b": 2, "c": 3}]`},
+		{`let h = {"a": 1, "b": 2}; [h.delete("a"), h, h.delete("x")]`, `[{"b": 2}, {"a": 1, "b": 2}, {"a": 1, "b": 2}]`},
+		{`{"a": 1}.set([1], 2)`, "ERROR: unusable as hash key: ARRAY"},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestUnicodeStrings(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`len("日本")`, "2"},
+		{`"日本語".len()`, "3"},
+		{`bytes_len("日本")`, "6"},
+		{`"日本語".bytes_len()`, "9"},
+		{`"日本語"[1]`, "本"},
+		{`"日本語"[-1]`, "語"},
+		{`"日本語"[3]`, "null"},
+		{`"こんにちは"[1:3]`, "んに"},
+		{`"こんにちは"[::-1]`, "はちにんこ"},
+		{`"あaい"[1]`, "a"},
+		{`[c for c in "東京"]`, `["東", "京"]`},
+		{`[i for i, c in "東京"]`, "[0, 1]"},
+		{`"京" in "東京都"`, "true"},
+		{`["東京, 大阪"]`, `["東京, 大阪"]`},
+		{`["東京", "大阪"]`, `["東京", "大阪"]`},
+		// 濁点を結合文字 ( U+3099 ) で付けた「が」は、コードポイントでは2文字、見た目では1文字
+		{`len("が")`, "1"},
+		{`len("` + "か\u3099" + `")`, "2"},
+		{`graphemes("` + "か\u3099ぎ" + `")`, "[\"か\u3099\", \"ぎ\"]"},
+		{`graphemes("👍🏽🇯🇵").len()`, "2"},
+		{`graphemes("👨‍👩‍👧").len()`, "1"},
+		{`"日本".graphemes()`, `["日", "本"]`},
+		{`graphemes("")`, "[]"},
+		{`bytes_len(1)`, "ERROR: argument to `bytes_len` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSets(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`#{1, 2, 3, 2, 1}`, "#{1, 2, 3}"},
+		{`#{}`, "#{}"},
+		{`#{"a", 1, true, "a"}`, `#{"a", 1, true}`},
+		{`set([3, 1, 3])`, "#{3, 1}"},
+		{`2 in #{1, 2}`, true},
+		{`"2" in #{1, 2}`, false},
+		{`#{1, 2}.contains(3)`, false},
+		{`len(#{1, 2, 2})`, 2},
+		{`#{1, 2}.len()`, 2},
+		{`#{1, 2}.union(#{2, 3})`, "#{1, 2, 3}"},
+		{`#{1, 2, 3}.intersection(#{3, 2, 5})`, "#{2, 3}"},
+		{`#{1, 2, 3}.difference(#{2})`, "#{1, 3}"},
+		{`let s = #{1}; let t = s.add(2); [s, t]`, "[#{1}, #{1, 2}]"},
+		{`#{1, 2}.add(2)`, "#{1, 2}"},
+		{`#{1, 2}.remove(1)`, "#{2}"},
+		{`#{1, 2, 3} == #{3, 2, 1}`, true},
+		{`#{1, 2} == #{1, 2, 3}`, false},
+		{`#{1, 2} != #{1}`, true},
+		{`[x * x for x in #{1, 2, 3}]`, "[1, 4, 9]"},
+		{`#{[1]}`, errorMessage("unusable as set element: ARRAY")},
+		{`[1] in #{1}`, errorMessage("unusable as set element: ARRAY")},
+		{`#{1}.union([1])`, errorMessage("argument to `union` must be SET, got ARRAY")},
+		{`set(1)`, errorMessage("argument to `set` must be ARRAY or SET, got INTEGER")},
+		{`#{1}[0]`, errorMessage("index operator not supported: SET")},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+}
+
+func TestInOperator(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"a" in {"a": 1}`, true},
+		{`"b" in {"a": 1}`, false},
+		{`[1, 2] in [[1, 2], 3]`, true},
+		{`3 in [1, 2]`, false},
+		{`"ell" in "hello"`, true},
+		{`"z" in "hello"`, false},
+		{`!(1 in [1])`, false},
+		{`1 in "1"`, errorMessage("type mismatch: INTEGER in STRING")},
+		{`1 in 2`, errorMessage("unknown operator: INTEGER in INTEGER")},
+		{`[1] in {"a": 1}`, errorMessage("unusable as hash key: ARRAY")},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+}
+
+func TestTuples(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`(1, "a", true)`, `(1, "a", true)`},
+		{`(1,)`, "(1,)"},
+		{`()`, "()"},
+		{`(1 + 2)`, 3},
+		{`(10, 20, 30)[1]`, 20},
+		{`(10, 20, 30)[-1]`, 30},
+		{`(10, 20)[2]`, nil},
+		{`(1, 2, 3, 4)[1:3]`, "(2, 3)"},
+		{`len((1, 2, 3))`, 3},
+		{`(1, 2).len()`, 2},
+		{`(1, 2).to_array()`, "[1, 2]"},
+		{`2 in (1, 2)`, true},
+		{`(1, 2).contains(3)`, false},
+		{`(1, (2, 3)) == (1, (2, 3))`, true},
+		{`(1, 2) == (2, 1)`, false},
+		{`(1, 2) == [1, 2]`, false},
+		{`[x * 2 for x in (1, 2, 3)]`, "[2, 4, 6]"},
+		{`let grid = {(0, 0): "origin", (1, 2): "p"}; grid[(1, 2)]`, "p"},
+		{`let grid = {(0, 0): "origin"}; let k = (0, 0); [grid[k], (0, 0) in grid, grid[(0, 1)]]`, `["origin", true, null]`},
+		{`let visits = {("ann", 1): 3, ("bob", 1): 5}; visits[("bob", 1)]`, 5},
+		{`{(1, 2): "a", (1, 2): "b"}`, `{(1, 2): "b"}`},
+		{`#{(1, 2), (1, 2), (2, 1)}`, "#{(1, 2), (2, 1)}"},
+		{`match ((3, 4)) { (0, y) => y, (x, y) => x * y }`, 12},
+		{`match ((1, 2, 3)) { (x, y) => 0, _ => -1 }`, -1},
+		{`match ((1,)) { (x,) => x }`, 1},
+		{`match ([1, 2]) { (x, y) => 0, [x, y] => x + y }`, 3},
+		{`{(1, [2]): 1}`, errorMessage("unusable as hash key: TUPLE")},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`b"hi\x00\xff"`, `b"hi\x00\xff"`},
+		{`len(b"hi\x00")`, 3},
+		{`b"hi".len()`, 2},
+		{`b"hi"[0]`, 104},
+		{`b"hi"[-1]`, 105},
+		{`b"hi"[2]`, nil},
+		{`b"hello"[1:3]`, `b"el"`},
+		{`b"ab" + b"\x00"`, `b"ab\x00"`},
+		{`b"ab" == b"ab"`, true},
+		{`b"ab" == "ab"`, false},
+		{`[x for x in b"AB"]`, "[65, 66]"},
+		{`104 in b"hi"`, true},
+		{`b"i" in b"hi"`, true},
+		{`300 in b"hi"`, false},
+		{`{b"k": 1}[b"k"]`, 1},
+		{`bytes([104, 105])`, `b"hi"`},
+		{`bytes("hé")`, `b"h\xc3\xa9"`},
+		{`bytes("hé", "latin-1")`, `b"h\xe9"`},
+		{`"hé".encode("UTF_8")`, `b"h\xc3\xa9"`},
+		{`b"h\xc3\xa9".decode()`, "hé"},
+		{`b"h\xe9".decode("latin1")`, "hé"},
+		{`b"hi".decode("ascii")`, "hi"},
+		{`b"hi\xff".hex()`, "6869ff"},
+		{`b"hi".to_text("base64")`, "aGk="},
+		{`b"hi\xff".to_text("HEX")`, "6869ff"},
+		{`from_hex("6869FF")`, `b"hi\xff"`},
+		{`from_text("aGk=", "base64")`, `b"hi"`},
+		{`from_text("6869ff", "hex")`, `b"hi\xff"`},
+		{`from_text(b"\x00\xfe".to_text("base64"), "base64") == b"\x00\xfe"`, true},
+		{`from_hex(b"\x00\x01\xfe".hex()) == b"\x00\x01\xfe"`, true},
+		{`"é".encode("ascii")`, errorMessage("cannot encode character 'é' as ascii")},
+		{`b"\xff".decode()`, errorMessage("cannot decode byte 0xff at position 0 as utf-8")},
+		{`b"a\x80".decode("ascii")`, errorMessage("cannot decode byte 0x80 at position 1 as ascii")},
+		{`"a".encode("ebcdic")`, errorMessage("unknown encoding: ebcdic")},
+		{`"a".encode(8)`, errorMessage("argument to `encode` must be STRING, got INTEGER")},
+		{`b"a".decode(8)`, errorMessage("argument to `decode` must be STRING, got INTEGER")},
+		{`bytes("a", 8)`, errorMessage("argument to `bytes` must be STRING, got INTEGER")},
+		{`bytes([256])`, errorMessage("byte out of range: 256")},
+		{`bytes(["a"])`, errorMessage("argument to `bytes` must be ARRAY of INTEGER, got STRING")},
+		{`from_hex("zz")`, errorMessage("invalid hex string: encoding/hex: invalid byte: U+007A 'z'")},
+		{`from_text("!", "base64")`, errorMessage("invalid base64 string: illegal base64 data at input byte 0")},
+		{`b"hi".to_text("rot13")`, errorMessage("unknown text format: rot13")},
+		{`b"hi".to_text(1)`, errorMessage("argument to `to_text` must be STRING, got INTEGER")},
+		{`from_text("aGk=")`, errorMessage("wrong number of arguments. got=1, want=2")},
+		{`"a" in b"a"`, errorMessage("type mismatch: STRING in BYTES")},
+		{`try { b"\xff".decode() } catch (e) { e["kind"] }`, "ValueError"},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestFileBuiltins(t *testing.T) {
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.bin")
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{fmt.Sprintf(`write_file(%q, b"\x00\x01\xff")`, path), 3},
+		{fmt.Sprintf(`read_bytes(%q)`, path), `b"\x00\x01\xff"`},
+		{fmt.Sprintf(`write_file(%q, "héllo")`, path), 6},
+		{fmt.Sprintf(`read_file(%q)`, path), "héllo"},
+		{fmt.Sprintf(`read_bytes(%q).decode()`, path), "héllo"},
+		{fmt.Sprintf(`write_file(%q, 1)`, path), errorMessage("argument to `write_file` must be STRING or BYTES, got INTEGER")},
+		{fmt.Sprintf(`try { read_file(%q) } catch (e) { e["kind"] }`, filepath.Join(dir, "missing")), "IOError"},
+		{`read_file(1)`, errorMessage("argument to `read_file` must be STRING, got INTEGER")},
+	}
+
+	for _, tt := range tests {
+
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case errorMessage:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+}
+
+func TestLiteralCaching(t *testing.T) {
+
+	program := parser.New(lexer.New(`"abc"`)).ParseProgram()
+	other := parser.New(lexer.New(`"abc"`)).ParseProgram()
+
+	first := Eval(program, object.NewEnvironment())
+
+	// 同じリテラルは同じオブジェクトを返し、別のリテラルとは共有しない
+	if Eval(program, object.NewEnvironment()) != first {
+		t.Errorf("string literal is not interned")
+	}
+
+	if Eval(other, object.NewEnvironment()) == first {
+		t.Errorf("different string literals share an object")
+	}
+
+	if testEval(`1 + 2`) != testEval(`6 / 2`) {
+		t.Errorf("small integer results are not cached")
+	}
+
+	testIntegerObject(t, testEval(`100000 * 3 + 7`), 300007)
+	testIntegerObject(t, testEval(`let x = 1024; x + 1`), 1025)
+}
+
+// 同じ構文木を複数のゴルーチンから評価しても、結果が混ざらない
+// .. go test -race ./evaluator で、リテラルの使い回しに競合が無いことも確かめる
+func TestConcurrentEval(t *testing.T) {
+
+	program := parser.New(lexer.New(`let f = fn(n, acc) { if (n == 0) { acc } else { f(n - 1, acc + "ab") } }; f(50, "")`)).ParseProgram()
+
+	results := make([]object.Object, 8)
+
+	var wg sync.WaitGroup
+
+	for i := range results {
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			results[i] = Eval(program, object.NewEnvironment())
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, result := range results {
+		testStringObject(t, result, strings.Repeat("ab", 50))
+	}
+}
+
+// 以下は割り当て回数を計る
+// .. go test -bench . -benchmem ./evaluator
+
+// 構文解析は計測の外で済ませ、評価だけを計る
+func benchmarkEval(b *testing.B, input string) {
+
+	program := parser.New(lexer.New(input)).ParseProgram()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Eval(program, object.NewEnvironment())
+	}
+}
+
+func BenchmarkFibonacci(b *testing.B) {
+	benchmarkEval(b, `let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(15)`)
+}
+
+func BenchmarkStringLiteralLoop(b *testing.B) {
+	benchmarkEval(b, `let loop = fn(n, acc) { if (n == 0) { acc } else { loop(n - 1, acc + len("abc")) } }; loop(500, 0)`)
+}
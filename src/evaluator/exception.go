@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"github.com/MasaruFukazawa/monkey-lang/src/ast"
+	"github.com/MasaruFukazawa/monkey-lang/src/object"
+)
+
+// エラーの種類
+// .. 実行時エラーは newError の呼び出し元で種類を指定する
+const (
+	thrownErrorKind       = "Error"             // throw文で文字列などを送出したとき
+	typeErrorKind         = "TypeError"         // 演算や呼び出しの対象の型が合わないとき
+	argumentErrorKind     = "ArgumentError"     // 引数の数や型が合わないとき
+	nameErrorKind         = "NameError"         // 未定義の名前や、定数への代入
+	keyErrorKind          = "KeyError"          // ハッシュキーとして使えない値
+	memberErrorKind       = "MemberError"       // 存在しないメンバーやバリアント
+	zeroDivisionErrorKind = "ZeroDivisionError" // 0 による除算
+	valueErrorKind        = "ValueError"        // 型は正しいが値が不正なとき
+	ioErrorKind           = "IOError"           // ファイルの読み書きに失敗したとき
+	matchErrorKind        = "MatchError"        // どの腕にも一致しない match 式
+	importErrorKind       = "ImportError"       // モジュールの読み込みに失敗したとき
+)
+
+/**
+ * 関数名: evalThrowStatement
+ * 処理: throw文を評価し、送出した値を持つエラーを返す
+ *  catch で受け取ったハッシュをそのまま送出した場合は、元のエラーを送出し直す
+ * 引数: throw文, 環境
+ * 戻値: エラー
+ */
+func evalThrowStatement(ts *ast.ThrowStatement, env *object.Environment) object.Object {
+
+	value := Eval(ts.Value, env)
+
+	if isError(value) {
+		return value
+	}
+
+	// 利用者が作ったハッシュは、キーによらず送出した値として扱う
+	if hash, ok := value.(*object.Hash); ok {
+		if original, ok := hash.Origin().(*object.Error); ok {
+			rethrown := *original
+			return &rethrown
+		}
+	}
+
+	err := &object.Error{Kind: thrownErrorKind, Value: value}
+
+	// 文字列はそのままメッセージとし、それ以外は値の表現をメッセージとする
+	if str, ok := value.(*object.String); ok {
+		err.Message = str.Value
+	} else {
+		err.Message = value.Inspect()
+	}
+
+	return err
+}
+
+/**
+ * 関数名: evalTryExpression
+ * 処理: try式を評価する
+ *  try のブロックがエラーになった場合は catch のブロックを評価し、その結果を値とする
+ *  finally のブロックは常に最後に評価し、エラーか return のときだけその結果で置き換える
+ * 引数: try式, 環境
+ * 戻値: 評価結果
+ */
+func evalTryExpression(te *ast.TryExpression, env *object.Environment) object.Object {
+
+	result := Eval(te.Block, env)
+
+	if err, ok := result.(*object.Error); ok && te.Catch != nil {
+
+		catchEnv := object.NewEnclosedEnvironment(env)
+
+		if te.Parameter != nil {
+			catchEnv.Set(te.Parameter.Value, errorToHash(err))
+		}
+
+		result = Eval(te.Catch, catchEnv)
+	}
+
+	if te.Finally != nil {
+
+		finally := Eval(te.Finally, env)
+
+		if isError(finally) {
+			return finally
+		}
+
+		if _, ok := finally.(*object.ReturnValue); ok {
+			return finally
+		}
+	}
+
+	return result
+}
+
+/**
+ * 関数名: errorToHash
+ * 処理: 捕捉したエラーを、message, kind, line, column, value を持つハッシュにする
+ *  ハッシュには元のエラーを記録し、throw で送出し直せるようにする
+ * 引数: エラー
+ * 戻値: ハッシュ
+ */
+func errorToHash(err *object.Error) *object.Hash {
+
+	value := err.Value
+
+	if value == nil {
+		value = NULL
+	}
+
+	fields := []struct {
+		key   string
+		value object.Object
+	}{
+		{"message", &object.String{Value: err.Message}},
+		{"kind", &object.String{Value: err.Kind}},
+		{"line", object.NewInteger(int64(err.Position.Line))},
+		{"column", object.NewInteger(int64(err.Position.Column))},
+		{"value", value},
+	}
+
+	hash := object.NewHash()
+
+	for _, field := range fields {
+		hash.Set(&object.String{Value: field.key}, field.value)
+	}
+
+	// 送出し直したときに元のエラーを復元できるように、元のエラーを記録する
+	hash.SetOrigin(err)
+
+	return hash
+}
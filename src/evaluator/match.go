@@ -0,0 +1,196 @@
+package evaluator
+
+import (
+	"github.com/MasaruFukazawa/monkey-lang/src/ast"
+	"github.com/MasaruFukazawa/monkey-lang/src/object"
+)
+
+/**
+ * 関数名: evalMatchExpression
+ * 処理: match式を評価する。腕を上から順に照合し、最初に一致した腕の式を評価する
+ * 引数: match式, 環境
+ * 戻値: 評価結果
+ */
+func evalMatchExpression(me *ast.MatchExpression, env *object.Environment) object.Object {
+
+	subject := Eval(me.Subject, env)
+
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range me.Arms {
+
+		// パターンで束縛した変数は、腕の中だけで有効にする
+		armEnv := object.NewEnclosedEnvironment(env)
+
+		matched, err := matchPattern(arm.Pattern, subject, armEnv)
+
+		if err != nil {
+			return err
+		}
+
+		if !matched {
+			continue
+		}
+
+		if arm.Guard != nil {
+
+			guard := Eval(arm.Guard, armEnv)
+
+			if isError(guard) {
+				return guard
+			}
+
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return Eval(arm.Body, armEnv)
+	}
+
+	return newError(matchErrorKind, "match is not exhaustive: no arm matched %s", object.Repr(subject))
+}
+
+/**
+ * 関数名: matchPattern
+ * 処理: 値がパターンに一致するかを判定し、一致した場合は変数を環境に束縛する
+ * 引数: パターン, 値, 環境
+ * 戻値: 一致したかどうか, エラー
+ */
+func matchPattern(pattern ast.Pattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+
+	switch pattern := pattern.(type) {
+
+	case *ast.WildcardPattern:
+		return true, nil
+
+	case *ast.BindingPattern:
+		env.Set(pattern.Name.Value, value)
+		return true, nil
+
+	case *ast.LiteralPattern:
+		literal := Eval(pattern.Value, env)
+
+		if err, ok := literal.(*object.Error); ok {
+			return false, err
+		}
+
+		return object.Equal(literal, value), nil
+
+	case *ast.ArrayPattern:
+		return matchArrayPattern(pattern, value, env)
+
+	case *ast.TuplePattern:
+		return matchTuplePattern(pattern, value, env)
+
+	case *ast.HashPattern:
+		return matchHashPattern(pattern, value, env)
+
+	case *ast.EnumPattern:
+		return matchEnumPattern(pattern, value, env)
+
+	default:
+		return false, newError(typeErrorKind, "unknown pattern: %s", pattern.String())
+	}
+}
+
+func matchArrayPattern(pattern *ast.ArrayPattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+
+	array, ok := value.(*object.Array)
+
+	if !ok {
+		return false, nil
+	}
+
+	// ...rest が無い場合は要素数が一致しなければならない
+	if array.Len() < len(pattern.Elements) {
+		return false, nil
+	}
+
+	if pattern.Rest == nil && array.Len() != len(pattern.Elements) {
+		return false, nil
+	}
+
+	for i, el := range pattern.Elements {
+
+		matched, err := matchPattern(el, array.At(i), env)
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if pattern.Rest != nil {
+
+		// 先頭の要素を読み飛ばした配列は、元の配列と要素を共有する
+		rest := array
+
+		for range pattern.Elements {
+			rest = rest.Rest()
+		}
+
+		env.Set(pattern.Rest.Value, rest)
+	}
+
+	return true, nil
+}
+
+func matchTuplePattern(pattern *ast.TuplePattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+
+	tuple, ok := value.(*object.Tuple)
+
+	if !ok || len(tuple.Elements) != len(pattern.Elements) {
+		return false, nil
+	}
+
+	for i, el := range pattern.Elements {
+
+		matched, err := matchPattern(el, tuple.Elements[i], env)
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func matchHashPattern(pattern *ast.HashPattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+
+	hash, ok := value.(*object.Hash)
+
+	if !ok {
+		return false, nil
+	}
+
+	for i, keyNode := range pattern.Keys {
+
+		key := Eval(keyNode, env)
+
+		if err, ok := key.(*object.Error); ok {
+			return false, err
+		}
+
+		hashKey, ok := object.AsHashable(key)
+
+		if !ok {
+			return false, newError(keyErrorKind, "unusable as hash key: %s", key.Type())
+		}
+
+		pair, ok := hash.Get(hashKey)
+
+		if !ok {
+			return false, nil
+		}
+
+		matched, err := matchPattern(pattern.Values[i], pair.Value, env)
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,580 @@
+package evaluator
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MasaruFukazawa/monkey-lang/src/object"
+)
+
+// 組み込み型のメソッドを表す関数
+// .. receiver にはメソッドを呼び出したオブジェクトが渡される
+type method func(receiver object.Object, args ...object.Object) object.Object
+
+// 組み込み型ごとのメソッドのテーブル
+// .. "abc".upper() や xs.push(1) のようにドット記法で呼び出す
+var methods = map[object.ObjectType]map[string]method{
+
+	object.STRING_OBJ: {
+		"len": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return object.NewInteger(int64(utf8.RuneCountInString(receiver.(*object.String).Value)))
+		},
+		"bytes_len": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return object.NewInteger(int64(len(receiver.(*object.String).Value)))
+		},
+		"graphemes": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return stringsToArray(graphemes(receiver.(*object.String).Value))
+		},
+		"upper": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return &object.String{Value: strings.ToUpper(receiver.(*object.String).Value)}
+		},
+		"lower": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return &object.String{Value: strings.ToLower(receiver.(*object.String).Value)}
+		},
+		"trim": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return &object.String{Value: strings.TrimSpace(receiver.(*object.String).Value)}
+		},
+		"split": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			sep, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError(argumentErrorKind, "argument to `split` must be STRING, got %s", args[0].Type())
+			}
+
+			parts := strings.Split(receiver.(*object.String).Value, sep.Value)
+			elements := make([]object.Object, len(parts))
+
+			for i, part := range parts {
+				elements[i] = &object.String{Value: part}
+			}
+
+			return object.NewArray(elements)
+		},
+		"encode": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) > 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+
+			encoding, err := encodingArgument("encode", args)
+
+			if err != nil {
+				return err
+			}
+
+			value, err := encodeString(receiver.(*object.String).Value, encoding)
+
+			if err != nil {
+				return err
+			}
+
+			return &object.Bytes{Value: value}
+		},
+		"contains": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			sub, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError(argumentErrorKind, "argument to `contains` must be STRING, got %s", args[0].Type())
+			}
+
+			return nativeBoolToBooleanObject(strings.Contains(receiver.(*object.String).Value, sub.Value))
+		},
+		"starts_with": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			prefix, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError(argumentErrorKind, "argument to `starts_with` must be STRING, got %s", args[0].Type())
+			}
+
+			return nativeBoolToBooleanObject(strings.HasPrefix(receiver.(*object.String).Value, prefix.Value))
+		},
+		"ends_with": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			suffix, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError(argumentErrorKind, "argument to `ends_with` must be STRING, got %s", args[0].Type())
+			}
+
+			return nativeBoolToBooleanObject(strings.HasSuffix(receiver.(*object.String).Value, suffix.Value))
+		},
+	},
+
+	object.ARRAY_OBJ: {
+		"len": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return object.NewInteger(int64(receiver.(*object.Array).Len()))
+		},
+		"first": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			arr := receiver.(*object.Array)
+
+			if arr.Len() > 0 {
+				return arr.At(0)
+			}
+
+			return NULL
+		},
+		"last": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			arr := receiver.(*object.Array)
+			length := arr.Len()
+
+			if length > 0 {
+				return arr.At(length - 1)
+			}
+
+			return NULL
+		},
+		"rest": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			if rest := receiver.(*object.Array).Rest(); rest != nil {
+				return rest
+			}
+
+			return NULL
+		},
+		"push": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			return receiver.(*object.Array).Push(args[0])
+		},
+		"set": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 2 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			index, ok := args[0].(*object.Integer)
+
+			if !ok {
+				return newError(argumentErrorKind, "argument to `set` must be INTEGER, got %s", args[0].Type())
+			}
+
+			arr := receiver.(*object.Array)
+
+			idx, ok := normalizeIndex(index.Value, arr.Len())
+
+			if !ok {
+				return newError(typeErrorKind, "index out of range: %d", index.Value)
+			}
+
+			return arr.With(int(idx), args[1])
+		},
+		"contains": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			return nativeBoolToBooleanObject(containsElement(receiver.(*object.Array).Elements(), args[0]))
+		},
+		"join": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			sep, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError(argumentErrorKind, "argument to `join` must be STRING, got %s", args[0].Type())
+			}
+
+			parts := []string{}
+
+			for _, el := range receiver.(*object.Array).Elements() {
+				parts = append(parts, el.Inspect())
+			}
+
+			return &object.String{Value: strings.Join(parts, sep.Value)}
+		},
+	},
+
+	object.HASH_OBJ: {
+		"len": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return object.NewInteger(int64(receiver.(*object.Hash).Len()))
+		},
+		"keys": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			keys := []object.Object{}
+
+			for _, pair := range receiver.(*object.Hash).Pairs() {
+				keys = append(keys, pair.Key)
+			}
+
+			return object.NewArray(keys)
+		},
+		"values": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			values := []object.Object{}
+
+			for _, pair := range receiver.(*object.Hash).Pairs() {
+				values = append(values, pair.Value)
+			}
+
+			return object.NewArray(values)
+		},
+		"has": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			key, ok := object.AsHashable(args[0])
+
+			if !ok {
+				return newError(keyErrorKind, "unusable as hash key: %s", args[0].Type())
+			}
+
+			_, ok = receiver.(*object.Hash).Get(key)
+
+			return nativeBoolToBooleanObject(ok)
+		},
+		"get": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 2 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			key, ok := object.AsHashable(args[0])
+
+			if !ok {
+				return newError(keyErrorKind, "unusable as hash key: %s", args[0].Type())
+			}
+
+			if pair, ok := receiver.(*object.Hash).Get(key); ok {
+				return pair.Value
+			}
+
+			return args[1]
+		},
+		"set": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 2 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			key, ok := object.AsHashable(args[0])
+
+			if !ok {
+				return newError(keyErrorKind, "unusable as hash key: %s", args[0].Type())
+			}
+
+			return receiver.(*object.Hash).With(key, args[1])
+		},
+		"delete": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			key, ok := object.AsHashable(args[0])
+
+			if !ok {
+				return newError(keyErrorKind, "unusable as hash key: %s", args[0].Type())
+			}
+
+			return receiver.(*object.Hash).Without(key)
+		},
+	},
+
+	object.BYTES_OBJ: {
+		"len": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return object.NewInteger(int64(len(receiver.(*object.Bytes).Value)))
+		},
+		"decode": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) > 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+
+			encoding, err := encodingArgument("decode", args)
+
+			if err != nil {
+				return err
+			}
+
+			s, err := decodeBytes(receiver.(*object.Bytes).Value, encoding)
+
+			if err != nil {
+				return err
+			}
+
+			return &object.String{Value: s}
+		},
+		"hex": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return &object.String{Value: bytesToText(receiver.(*object.Bytes).Value, "hex")}
+		},
+		"to_text": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			format, err := textFormatArgument("to_text", args[0])
+
+			if err != nil {
+				return err
+			}
+
+			return &object.String{Value: bytesToText(receiver.(*object.Bytes).Value, format)}
+		},
+	},
+
+	object.TUPLE_OBJ: {
+		"len": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return object.NewInteger(int64(len(receiver.(*object.Tuple).Elements)))
+		},
+		"contains": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			return nativeBoolToBooleanObject(containsElement(receiver.(*object.Tuple).Elements, args[0]))
+		},
+		"to_array": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			elements := make([]object.Object, len(receiver.(*object.Tuple).Elements))
+			copy(elements, receiver.(*object.Tuple).Elements)
+
+			return object.NewArray(elements)
+		},
+	},
+
+	// セットのメソッドは、レシーバを変更せずに新しいセットを返す
+	object.SET_OBJ: {
+		"len": func(receiver object.Object, args ...object.Object) object.Object {
+
+			if len(args) != 0 {
+				return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+
+			return object.NewInteger(int64(receiver.(*object.Set).Len()))
+		},
+		"contains": func(receiver object.Object, args ...object.Object) object.Object {
+
+			el, err := setElement(args)
+
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBooleanObject(receiver.(*object.Set).Contains(el))
+		},
+		"add": func(receiver object.Object, args ...object.Object) object.Object {
+
+			el, err := setElement(args)
+
+			if err != nil {
+				return err
+			}
+
+			return object.NewSet(append(receiver.(*object.Set).Elements(), el)...)
+		},
+		"remove": func(receiver object.Object, args ...object.Object) object.Object {
+
+			el, err := setElement(args)
+
+			if err != nil {
+				return err
+			}
+
+			return receiver.(*object.Set).Difference(object.NewSet(el))
+		},
+		"union": func(receiver object.Object, args ...object.Object) object.Object {
+
+			other, err := setArgument("union", args)
+
+			if err != nil {
+				return err
+			}
+
+			return receiver.(*object.Set).Union(other)
+		},
+		"intersection": func(receiver object.Object, args ...object.Object) object.Object {
+
+			other, err := setArgument("intersection", args)
+
+			if err != nil {
+				return err
+			}
+
+			return receiver.(*object.Set).Intersection(other)
+		},
+		"difference": func(receiver object.Object, args ...object.Object) object.Object {
+
+			other, err := setArgument("difference", args)
+
+			if err != nil {
+				return err
+			}
+
+			return receiver.(*object.Set).Difference(other)
+		},
+	},
+}
+
+/**
+ * 関数名: setArgument
+ * 処理: セットのメソッドの引数がセットであることを検査する
+ * 引数: メソッド名, 引数
+ * 戻値: セット, エラー
+ */
+func setArgument(name string, args []object.Object) (*object.Set, object.Object) {
+
+	if len(args) != 1 {
+		return nil, newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	set, ok := args[0].(*object.Set)
+
+	if !ok {
+		return nil, newError(argumentErrorKind, "argument to `%s` must be SET, got %s", name, args[0].Type())
+	}
+
+	return set, nil
+}
+
+/**
+ * 関数名: setElement
+ * 処理: セットのメソッドの引数が、セットの要素として使えることを検査する
+ * 引数: 引数
+ * 戻値: 要素, エラー
+ */
+func setElement(args []object.Object) (object.Hashable, object.Object) {
+
+	if len(args) != 1 {
+		return nil, newError(argumentErrorKind, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	el, ok := object.AsHashable(args[0])
+
+	if !ok {
+		return nil, newError(typeErrorKind, "unusable as set element: %s", args[0].Type())
+	}
+
+	return el, nil
+}
+
+/**
+ * 関数名: lookupMethod
+ * 処理: 組み込み型のメソッドを探し、レシーバを束縛した組み込み関数として返す
+ * 引数: レシーバ, メソッド名
+ * 戻値: 組み込み関数, 見つかったかどうか
+ */
+func lookupMethod(receiver object.Object, name string) (*object.Builtin, bool) {
+
+	fn, ok := methods[receiver.Type()][name]
+
+	if !ok {
+		return nil, false
+	}
+
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return fn(receiver, args...)
+		},
+	}, true
+}
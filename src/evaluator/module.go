@@ -0,0 +1,264 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MasaruFukazawa/monkey-lang/src/ast"
+	"github.com/MasaruFukazawa/monkey-lang/src/lexer"
+	"github.com/MasaruFukazawa/monkey-lang/src/object"
+	"github.com/MasaruFukazawa/monkey-lang/src/parser"
+)
+
+// import文で使うモジュールローダ
+// .. monkey コマンドが起動時に検索パスを設定する
+var DefaultLoader = NewModuleLoader()
+
+// モジュールローダを表す構造体
+// .. 各ファイルは一度だけ構文解析・評価し、結果のモジュールを使い回す
+type ModuleLoader struct {
+	SearchPaths []string                  // 相対パスで見つからなかったモジュールを探すディレクトリ
+	modules     map[string]*object.Module // 読み込み済みのモジュール ( 絶対パス → モジュール )
+	loading     []string                  // 読み込み中のファイルの絶対パス ( 末尾が現在のファイル )
+}
+
+/**
+ * 関数名: NewModuleLoader
+ * 処理: モジュールローダを生成する
+ * 引数: 検索パス
+ * 戻値: *ModuleLoader
+ */
+func NewModuleLoader(searchPaths ...string) *ModuleLoader {
+	return &ModuleLoader{
+		SearchPaths: searchPaths,
+		modules:     make(map[string]*object.Module),
+	}
+}
+
+/**
+ * 関数名: ModuleLoader.Run
+ * 処理: ファイルをプログラムとして読み込み、環境で評価する
+ *  実行中のファイルは、そのファイルを読み込むimport文の循環の検出に使う
+ * 引数: ファイルのパス, 環境
+ * 戻値: 評価結果
+ */
+func (l *ModuleLoader) Run(path string, env *object.Environment) object.Object {
+
+	abs, err := filepath.Abs(path)
+
+	if err != nil {
+		return newError(importErrorKind, "cannot resolve %q: %s", path, err)
+	}
+
+	program, errObj := l.parseFile(abs)
+
+	if errObj != nil {
+		return errObj
+	}
+
+	l.loading = append(l.loading, abs)
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	return Eval(program, env)
+}
+
+/**
+ * 関数名: ModuleLoader.Import
+ * 処理: モジュールを読み込む。読み込み済みであれば、前回の結果を返す
+ * 引数: import文に書かれたパス
+ * 戻値: モジュール, またはエラー
+ */
+func (l *ModuleLoader) Import(path string) object.Object {
+
+	abs, errObj := l.resolve(path)
+
+	if errObj != nil {
+		return errObj
+	}
+
+	if module, ok := l.modules[abs]; ok {
+		return module
+	}
+
+	// 読み込み中のファイルを再び読み込もうとした場合は、循環したimportとする
+	for i, loading := range l.loading {
+
+		if loading != abs {
+			continue
+		}
+
+		chain := []string{}
+
+		for _, p := range l.loading[i:] {
+			chain = append(chain, displayPath(p))
+		}
+
+		chain = append(chain, displayPath(abs))
+
+		return newError(importErrorKind, "import cycle detected: %s", strings.Join(chain, " -> "))
+	}
+
+	program, errObj := l.parseFile(abs)
+
+	if errObj != nil {
+		return errObj
+	}
+
+	l.loading = append(l.loading, abs)
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	// モジュールは読み込み元とは独立した環境で評価する
+	env := object.NewEnvironment()
+
+	if result := Eval(program, env); isError(result) {
+		return result
+	}
+
+	base := filepath.Base(abs)
+
+	module := &object.Module{
+		Name:    strings.TrimSuffix(base, filepath.Ext(base)),
+		Path:    displayPath(abs),
+		Exports: make(map[string]object.Object),
+	}
+
+	for _, stmt := range program.Statements {
+
+		export, ok := stmt.(*ast.ExportStatement)
+
+		if !ok {
+			continue
+		}
+
+		name := declaredName(export.Statement)
+
+		if val, ok := env.Get(name); ok {
+			module.Exports[name] = val
+		}
+	}
+
+	l.modules[abs] = module
+
+	return module
+}
+
+/**
+ * 関数名: ModuleLoader.resolve
+ * 処理: import文に書かれたパスをファイルの絶対パスに変換する
+ *  ./ または ../ で始まるパスは読み込み元のファイルのディレクトリから探す
+ *  それ以外のパスは読み込み元のディレクトリ、検索パスの順に探す
+ * 引数: import文に書かれたパス
+ * 戻値: 絶対パス, エラー
+ */
+func (l *ModuleLoader) resolve(path string) (string, *object.Error) {
+
+	// 読み込み元のファイルが無い場合 ( REPL ) は、作業ディレクトリから探す
+	dir := "."
+
+	if len(l.loading) > 0 {
+		dir = filepath.Dir(l.loading[len(l.loading)-1])
+	}
+
+	candidates := []string{}
+
+	switch {
+	case filepath.IsAbs(path):
+		candidates = append(candidates, path)
+	case strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../"):
+		candidates = append(candidates, filepath.Join(dir, path))
+	default:
+		candidates = append(candidates, filepath.Join(dir, path))
+
+		for _, searchPath := range l.SearchPaths {
+			candidates = append(candidates, filepath.Join(searchPath, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+
+		info, err := os.Stat(candidate)
+
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		abs, err := filepath.Abs(candidate)
+
+		if err != nil {
+			return "", newError(importErrorKind, "cannot resolve %q: %s", path, err)
+		}
+
+		return abs, nil
+	}
+
+	return "", newError(importErrorKind, "module not found: %q", path)
+}
+
+/**
+ * 関数名: ModuleLoader.parseFile
+ * 処理: ファイルを読み込んで構文解析する
+ * 引数: ファイルの絶対パス
+ * 戻値: プログラム, エラー
+ */
+func (l *ModuleLoader) parseFile(abs string) (*ast.Program, *object.Error) {
+
+	source, err := os.ReadFile(abs)
+
+	if err != nil {
+		return nil, newError(importErrorKind, "cannot read %s: %s", displayPath(abs), err)
+	}
+
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		return nil, newError(importErrorKind, "parse errors in %s: %s", displayPath(abs), strings.Join(p.Errors(), "; "))
+	}
+
+	return program, nil
+}
+
+/**
+ * 関数名: declaredName
+ * 処理: 宣言の文が束縛する名前を返す
+ * 引数: 宣言の文
+ * 戻値: 名前。宣言でなければ空文字列
+ */
+func declaredName(stmt ast.Statement) string {
+
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Name.Value
+	case *ast.StructStatement:
+		return stmt.Name.Value
+	case *ast.EnumStatement:
+		return stmt.Name.Value
+	default:
+		return ""
+	}
+}
+
+/**
+ * 関数名: displayPath
+ * 処理: エラーメッセージに表示するパスを返す
+ *  作業ディレクトリの中のファイルは相対パス、それ以外は絶対パスとする
+ * 引数: 絶対パス
+ * 戻値: 表示するパス
+ */
+func displayPath(abs string) string {
+
+	wd, err := os.Getwd()
+
+	if err != nil {
+		return abs
+	}
+
+	rel, err := filepath.Rel(wd, abs)
+
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return abs
+	}
+
+	return rel
+}
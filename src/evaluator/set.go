@@ -0,0 +1,123 @@
+package evaluator
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/MasaruFukazawa/monkey-lang/src/ast"
+	"github.com/MasaruFukazawa/monkey-lang/src/object"
+)
+
+/**
+ * 関数名: evalSetLiteral
+ * 処理: セットリテラルを評価する。重複する要素は1つにまとめる
+ * 引数: セットリテラル, 環境
+ * 戻値: 評価結果
+ */
+func evalSetLiteral(node *ast.SetLiteral, env *object.Environment) object.Object {
+
+	elements := evalExpressions(node.Elements, env)
+
+	if len(elements) == 1 && isError(elements[0]) {
+		return elements[0]
+	}
+
+	return newSet(elements)
+}
+
+/**
+ * 関数名: newSet
+ * 処理: 値を要素とするセットを生成する
+ * 引数: 値
+ * 戻値: セット : ハッシュキーとして使えない値がある場合はエラー
+ */
+func newSet(elements []object.Object) object.Object {
+
+	set := object.NewSet()
+
+	for _, el := range elements {
+
+		hashable, ok := object.AsHashable(el)
+
+		if !ok {
+			return newError(typeErrorKind, "unusable as set element: %s", el.Type())
+		}
+
+		set.Add(hashable)
+	}
+
+	return set
+}
+
+/**
+ * 関数名: evalInExpression
+ * 処理: in 演算子を評価する
+ *  セットは要素、ハッシュはキー、配列・タプルは要素、文字列は部分文字列として含まれるかを判定する
+ *  バイト列は、整数であればその値のバイト、バイト列であれば部分列として含まれるかを判定する
+ * 引数: 左辺, 右辺
+ * 戻値: 評価結果
+ */
+func evalInExpression(left, right object.Object) object.Object {
+
+	switch right := right.(type) {
+	case *object.Set:
+		el, ok := object.AsHashable(left)
+
+		if !ok {
+			return newError(typeErrorKind, "unusable as set element: %s", left.Type())
+		}
+
+		return nativeBoolToBooleanObject(right.Contains(el))
+
+	case *object.Hash:
+		key, ok := object.AsHashable(left)
+
+		if !ok {
+			return newError(keyErrorKind, "unusable as hash key: %s", left.Type())
+		}
+
+		_, ok = right.Get(key)
+
+		return nativeBoolToBooleanObject(ok)
+
+	case *object.Array:
+		return nativeBoolToBooleanObject(containsElement(right.Elements(), left))
+
+	case *object.Tuple:
+		return nativeBoolToBooleanObject(containsElement(right.Elements, left))
+
+	case *object.String:
+		sub, ok := left.(*object.String)
+
+		if !ok {
+			return newError(typeErrorKind, "type mismatch: %s in %s", left.Type(), right.Type())
+		}
+
+		return nativeBoolToBooleanObject(strings.Contains(right.Value, sub.Value))
+
+	case *object.Bytes:
+		switch left := left.(type) {
+		case *object.Integer:
+			return nativeBoolToBooleanObject(left.Value >= 0 && left.Value <= 255 && bytes.IndexByte(right.Value, byte(left.Value)) >= 0)
+		case *object.Bytes:
+			return nativeBoolToBooleanObject(bytes.Contains(right.Value, left.Value))
+		default:
+			return newError(typeErrorKind, "type mismatch: %s in %s", left.Type(), right.Type())
+		}
+
+	default:
+		return newError(typeErrorKind, "unknown operator: %s in %s", left.Type(), right.Type())
+	}
+}
+
+// 値と等しい要素があればtrueを返す
+func containsElement(elements []object.Object, value object.Object) bool {
+
+	for _, el := range elements {
+		if object.Equal(el, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+package evaluator
+
+import (
+	"unicode"
+
+	"github.com/MasaruFukazawa/monkey-lang/src/ast"
+	"github.com/MasaruFukazawa/monkey-lang/src/object"
+)
+
+// 文字列はコードポイントの並びとして扱う
+// .. len, 添字, スライス, for-in はコードポイント単位で数える
+// .. バイト数は bytes_len で、見た目の1文字 ( 書記素クラスタ ) は graphemes で求める
+// .. UTF-8 として正しくないバイトは、1つずつ U+FFFD として数える
+
+const zeroWidthJoiner = '\u200d'
+
+/**
+ * 関数名: evalStringLiteral
+ * 処理: 文字列リテラルを評価する。2回目以降は、初回に生成した文字列オブジェクトを返す
+ *  文字列オブジェクトは値を変更しないので、ループや再帰で同じリテラルを何度評価しても使い回す
+ *  生成したオブジェクトはノードに持たせるので、構文木を捨てれば一緒に解放される
+ * 引数: 文字列リテラル
+ * 戻値: 文字列オブジェクト
+ */
+func evalStringLiteral(node *ast.StringLiteral) *object.String {
+
+	if str, ok := node.Interned.Load().(*object.String); ok {
+		return str
+	}
+
+	// 同時に評価した場合は、先に入れた方を使う
+	node.Interned.CompareAndSwap(nil, &object.String{Value: node.Value})
+
+	return node.Interned.Load().(*object.String)
+}
+
+/**
+ * 関数名: graphemes
+ * 処理: 文字列を書記素クラスタ ( 見た目の1文字 ) に分割する
+ *  Unicode の規則 ( UAX #29 ) のうち、結合文字・異体字セレクタ・絵文字の修飾子と ZWJ 連結・
+ *  国旗 ( 地域指示記号の組 ) ・CR LF を1文字にまとめる規則に対応する
+ * 引数: 文字列
+ * 戻値: 書記素クラスタの一覧
+ */
+func graphemes(s string) []string {
+
+	clusters := []string{}
+
+	start := 0
+	prev := rune(-1)
+	regional := 0 // クラスタの中で続いている地域指示記号の数
+
+	for i, r := range s {
+
+		if prev >= 0 && isGraphemeBoundary(prev, r, regional) {
+			clusters = append(clusters, s[start:i])
+			start = i
+			regional = 0
+		}
+
+		if isRegionalIndicator(r) {
+			regional++
+		}
+
+		prev = r
+	}
+
+	if start < len(s) {
+		clusters = append(clusters, s[start:])
+	}
+
+	return clusters
+}
+
+// prev と r の間で書記素クラスタが分かれる場合はtrueを返す
+func isGraphemeBoundary(prev, r rune, regional int) bool {
+
+	switch {
+	case prev == '\r' && r == '\n':
+		return false
+	case unicode.IsControl(prev) || unicode.IsControl(r):
+		return true
+	case isGraphemeExtend(r):
+		return false
+	case prev == zeroWidthJoiner:
+		return false
+	case isRegionalIndicator(prev) && isRegionalIndicator(r):
+		// 地域指示記号は2つずつ組にする
+		return regional%2 == 0
+	default:
+		return true
+	}
+}
+
+// 直前の文字に続けて1文字にする文字であればtrueを返す
+// .. 結合文字 ( 濁点 U+3099 など ) , 異体字セレクタ, ZWJ, 絵文字の肌の色の修飾子, タグ文字
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == zeroWidthJoiner ||
+		('\U0001F3FB' <= r && r <= '\U0001F3FF') ||
+		('\U000E0020' <= r && r <= '\U000E007F')
+}
+
+// 国旗を表す地域指示記号であればtrueを返す
+func isRegionalIndicator(r rune) bool {
+	return '\U0001F1E6' <= r && r <= '\U0001F1FF'
+}
+
+/**
+ * 関数名: stringsToArray
+ * 処理: 文字列の一覧を文字列の配列に変換する
+ * 引数: 文字列の一覧
+ * 戻値: 配列
+ */
+func stringsToArray(values []string) *object.Array {
+
+	elements := make([]object.Object, len(values))
+
+	for i, value := range values {
+		elements[i] = &object.String{Value: value}
+	}
+
+	return object.NewArray(elements)
+}
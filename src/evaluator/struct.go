@@ -0,0 +1,88 @@
+package evaluator
+
+import (
+	"github.com/MasaruFukazawa/monkey-lang/src/ast"
+	"github.com/MasaruFukazawa/monkey-lang/src/object"
+)
+
+/**
+ * 関数名: evalStructStatement
+ * 処理: 構造体の定義を評価し、構造体の型を名前に束縛する
+ *  メソッドは定義した環境を閉じ込めた関数とする
+ * 引数: struct文, 環境
+ * 戻値: エラー : 束縛できた場合は nil
+ */
+func evalStructStatement(ss *ast.StructStatement, env *object.Environment) object.Object {
+
+	if env.IsConst(ss.Name.Value) {
+		return newError(nameErrorKind, "cannot redeclare constant %s", ss.Name.Value)
+	}
+
+	st := &object.StructType{
+		Name:    ss.Name.Value,
+		Methods: make(map[string]*object.Function),
+	}
+
+	for _, field := range ss.Fields {
+		st.Fields = append(st.Fields, field.Value)
+	}
+
+	for _, method := range ss.Methods {
+		st.Methods[method.Name.Value] = &object.Function{
+			Parameters: method.Function.Parameters,
+			Body:       method.Function.Body,
+			Env:        env,
+		}
+	}
+
+	env.Set(ss.Name.Value, st)
+
+	return nil
+}
+
+/**
+ * 関数名: newInstance
+ * 処理: 構造体の型を呼び出し、引数をフィールドの順に割り当てたインスタンスを生成する
+ * 引数: 構造体の型, 引数
+ * 戻値: インスタンス
+ */
+func newInstance(st *object.StructType, args []object.Object) object.Object {
+
+	if len(args) != len(st.Fields) {
+		return newError(argumentErrorKind, "wrong number of arguments. got=%d, want=%d", len(args), len(st.Fields))
+	}
+
+	fields := make(map[string]object.Object, len(st.Fields))
+
+	for i, name := range st.Fields {
+		fields[name] = args[i]
+	}
+
+	return &object.Instance{Struct: st, Fields: fields}
+}
+
+/**
+ * 関数名: evalInstanceMember
+ * 処理: インスタンスのフィールドの値、またはレシーバを束縛したメソッドを返す
+ *  フィールドを先に探し、見つからなければメソッドを探す
+ * 引数: インスタンス, メンバ名
+ * 戻値: 評価結果
+ */
+func evalInstanceMember(instance *object.Instance, name string) object.Object {
+
+	if val, ok := instance.Fields[name]; ok {
+		return val
+	}
+
+	method, ok := instance.Struct.Methods[name]
+
+	if !ok {
+		return newError(memberErrorKind, "unknown member: %s.%s", instance.Struct.Name, name)
+	}
+
+	// self をレシーバに束縛した環境で、メソッドの本体を評価する
+	env := object.NewEnclosedEnvironment(method.Env)
+	env.Set("self", instance)
+
+	return &object.Function{Parameters: method.Parameters, Body: method.Body, Env: env}
+}
@@ -16,6 +16,8 @@ type Lexer struct {
 	position     int    // 入力における現在の位置 : 現在の文字を指し示す。 初期値は0
 	readPosition int    // これから読み込む位置 : 現在の文字の次を指し示す。初期値は0
 	ch           byte   // 現在検査中の1文字
+	line         int    // 現在検査中の文字の行番号 : 1から始まる
+	column       int    // 現在検査中の文字の列番号 : 1から始まる
 }
 
 /**
@@ -26,6 +28,14 @@ type Lexer struct {
  */
 func (l *Lexer) readChar() {
 
+	// 改行を読み終えたら、次の行の先頭に移る
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+
+	l.column += 1
+
 	// 入力が終端に達しているかどうかを検査
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
@@ -52,6 +62,9 @@ func (l *Lexer) NextToken() token.Token {
 	// 空白文字を読み飛ばす
 	l.skipWhitespace()
 
+	// トークンの開始位置を記憶
+	pos := token.Position{Line: l.line, Column: l.column}
+
 	// 現在検査中の文字に応じてトークンを返す
 	// .. default 以外は、1文字で意味が完結するトークン
 	switch l.ch {
@@ -62,13 +75,24 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.EQ, Literal: literal}
+		} else if l.peekChar() == '>' { // => であれば、ARROWトークンとする
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.ARROW, Literal: literal}
 		} else { // = であれば、ASSIGNトークンとする
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case '+':
 		tok = newToken(token.PLUS, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		// -> であれば、RARROWトークンとする
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.RARROW, Literal: "->"}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		// 1文字前を覗き見する
 		if l.peekChar() == '=' {
@@ -108,6 +132,46 @@ func (l *Lexer) NextToken() token.Token {
 		tok.Literal = l.readString()
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '?':
+		// 1文字前を覗き見して、??, ?., ?[ のいずれかとする
+		switch l.peekChar() {
+		case '?':
+			l.readChar()
+			tok = token.Token{Type: token.NULLISH, Literal: "??"}
+		case '.':
+			l.readChar()
+			tok = token.Token{Type: token.OPTIONAL_DOT, Literal: "?."}
+		case '[':
+			l.readChar()
+			tok = token.Token{Type: token.OPTIONAL_LBRACKET, Literal: "?["}
+		default:
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '#':
+		// #{ であれば、セットリテラルの開始とする
+		if l.peekChar() == '{' {
+			l.readChar()
+			tok = token.Token{Type: token.SET_LBRACE, Literal: "#{"}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '|':
+		// |> であれば、PIPELINEトークンとする
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.PIPELINE, Literal: "|>"}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '.':
+		// ... であれば、ELLIPSISトークンとする
+		if l.peekChar() == '.' && l.peekCharAt(1) == '.' {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = newToken(token.DOT, l.ch)
+		}
 	case 0: // ソースコードの終端に達した場合
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -115,14 +179,22 @@ func (l *Lexer) NextToken() token.Token {
 	// ユーザ定義の識別子(変数名・関数名)を読み込む
 	default:
 
-		// 文字が英字である限り、識別子として読み込む
-		if isLetter(l.ch) {
+		// b" で始まる場合は、バイト列として読み込む
+		if l.ch == 'b' && l.peekChar() == '"' {
+
+			l.readChar()
+
+			tok.Type = token.BYTES
+			tok.Literal = l.readBytes()
+
+		} else if isLetter(l.ch) {
 
 			// 識別子(変数名・関数名)を取得する
 			tok.Literal = l.readIdentifier()
 
 			// 識別子(変数名・関数名)の種類を判定する
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Position = pos
 
 			return tok
 
@@ -132,6 +204,7 @@ func (l *Lexer) NextToken() token.Token {
 			// 整数を取得する
 			tok.Type = token.INT
 			tok.Literal = l.readNumber()
+			tok.Position = pos
 
 			return tok
 
@@ -145,9 +218,38 @@ func (l *Lexer) NextToken() token.Token {
 	// 1文字読み込む
 	l.readChar()
 
+	tok.Position = pos
+
 	return tok
 }
 
+/**
+ * 名前: readBytes
+ * 処理: バイト列のリテラルを読み込む
+ *  エスケープシーケンスはそのまま残し、構文解析器で解釈する。\" は終端としない
+ * 引数: なし
+ * 戻値: 引用符の間の文字列
+ */
+func (l *Lexer) readBytes() string {
+
+	position := l.position + 1
+
+	for {
+		l.readChar()
+
+		if l.ch == '\\' && l.peekChar() != 0 {
+			l.readChar()
+			continue
+		}
+
+		if l.ch == '"' || l.ch == 0 {
+			break
+		}
+	}
+
+	return l.input[position:l.position]
+}
+
 /**
  * 名前: readIdentifier
  * 処理: 識別子(変数名・関数名)を読み込む
@@ -235,6 +337,21 @@ func (l *Lexer) peekChar() byte {
 
 }
 
+/**
+ * 名前: peekCharAt
+ * 処理: 次の文字からoffset文字先の文字を読み込む。ただし、読み込み位置は進めない
+ * 引数: offset: 次の文字からのずれ
+ * 戻り値: 文字
+ */
+func (l *Lexer) peekCharAt(offset int) byte {
+
+	if l.readPosition+offset >= len(l.input) {
+		return 0
+	}
+
+	return l.input[l.readPosition+offset]
+}
+
 /**
  * 名前: readString
  * 処理: 文字列を読み込む
@@ -264,7 +381,7 @@ func (l *Lexer) readString() string {
 func New(input string) *Lexer {
 
 	// lexer構造体のポインタを返す
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 
 	// 1文字読み込む
 	// .. l.ch = l.input[0]
@@ -37,6 +37,21 @@ if (5 < 10) {
 "foo bar"
 [1, 2];
 {"foo": "bar"}
+match (x) { [a, ...b] => a }
+a?.b?["c"] ?? null
+xs |> f()
+h.keys()
+const c = 1;
+[x for x in xs]
+import "lib/math.monkey" as m;
+export let y = 1;
+try { throw e } catch (e) {} finally {}
+defer f();
+struct P { x }
+enum E { A }
+fn(a: int) -> int
+#{1} in s
+b"a\"b" b
 `
 
 	// テスト結果となるトークンの期待値を定義
@@ -164,6 +179,121 @@ if (5 < 10) {
 		{token.STRING, "bar"},
 		{token.RBRACE, "}"},
 
+		// match式
+		{token.MATCH, "match"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.LBRACKET, "["},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "b"},
+		{token.RBRACKET, "]"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "a"},
+		{token.RBRACE, "}"},
+
+		// オプショナルアクセスとNULL合体演算子
+		{token.IDENT, "a"},
+		{token.OPTIONAL_DOT, "?."},
+		{token.IDENT, "b"},
+		{token.OPTIONAL_LBRACKET, "?["},
+		{token.STRING, "c"},
+		{token.RBRACKET, "]"},
+		{token.NULLISH, "??"},
+		{token.NULL, "null"},
+
+		// パイプライン演算子
+		{token.IDENT, "xs"},
+		{token.PIPELINE, "|>"},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+
+		// メンバアクセス
+		{token.IDENT, "h"},
+		{token.DOT, "."},
+		{token.IDENT, "keys"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+
+		// const文
+		{token.CONST, "const"},
+		{token.IDENT, "c"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+
+		// 内包表記
+		{token.LBRACKET, "["},
+		{token.IDENT, "x"},
+		{token.FOR, "for"},
+		{token.IDENT, "x"},
+		{token.IN, "in"},
+		{token.IDENT, "xs"},
+		{token.RBRACKET, "]"},
+
+		// モジュール
+		{token.IMPORT, "import"},
+		{token.STRING, "lib/math.monkey"},
+		{token.AS, "as"},
+		{token.IDENT, "m"},
+		{token.SEMICOLON, ";"},
+		{token.EXPORT, "export"},
+		{token.LET, "let"},
+		{token.IDENT, "y"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+
+		// 例外
+		{token.TRY, "try"},
+		{token.LBRACE, "{"},
+		{token.THROW, "throw"},
+		{token.IDENT, "e"},
+		{token.RBRACE, "}"},
+		{token.CATCH, "catch"},
+		{token.LPAREN, "("},
+		{token.IDENT, "e"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.FINALLY, "finally"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.DEFER, "defer"},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.STRUCT, "struct"},
+		{token.IDENT, "P"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "x"},
+		{token.RBRACE, "}"},
+		{token.ENUM, "enum"},
+		{token.IDENT, "E"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "A"},
+		{token.RBRACE, "}"},
+		{token.FUNCTION, "fn"},
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.COLON, ":"},
+		{token.IDENT, "int"},
+		{token.RPAREN, ")"},
+		{token.RARROW, "->"},
+		{token.IDENT, "int"},
+		{token.SET_LBRACE, "#{"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.IN, "in"},
+		{token.IDENT, "s"},
+		{token.BYTES, `a\"b`},
+		{token.IDENT, "b"},
+
 		// ファイルの終端
 		{token.EOF, ""},
 	}
@@ -188,3 +318,40 @@ if (5 < 10) {
 
 	}
 }
+
+// トークンの開始位置 ( 行, 列 ) のテスト
+func TestTokenPosition(t *testing.T) {
+
+	input := "let x = 5;\n  x == \"ab\"\n"
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.LET, 1, 1},
+		{token.IDENT, 1, 5},
+		{token.ASSIGN, 1, 7},
+		{token.INT, 1, 9},
+		{token.SEMICOLON, 1, 10},
+		{token.IDENT, 2, 3},
+		{token.EQ, 2, 5},
+		{token.STRING, 2, 8},
+		{token.EOF, 3, 1},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%s", i, tt.expectedLine, tt.expectedColumn, tok.Position)
+		}
+	}
+}
@@ -4,12 +4,31 @@ import (
 	"fmt"
 	"os"
 	"os/user"
+	"path/filepath"
 
+	"github.com/MasaruFukazawa/monkey-lang/src/checker"
+	"github.com/MasaruFukazawa/monkey-lang/src/evaluator"
+	"github.com/MasaruFukazawa/monkey-lang/src/lexer"
+	"github.com/MasaruFukazawa/monkey-lang/src/object"
+	"github.com/MasaruFukazawa/monkey-lang/src/parser"
 	"github.com/MasaruFukazawa/monkey-lang/src/repl"
 )
 
 func main() {
 
+	// import文でモジュールを探すディレクトリを、環境変数 MONKEY_PATH から設定する
+	evaluator.DefaultLoader.SearchPaths = filepath.SplitList(os.Getenv("MONKEY_PATH"))
+
+	// monkey check ファイル... の場合は、ファイルを実行せずに型を検査する
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(check(os.Args[2:]))
+	}
+
+	// 引数にファイルが指定された場合は、ファイルを実行する
+	if len(os.Args) > 1 {
+		os.Exit(run(os.Args[1]))
+	}
+
 	// ユーザー名を取得
 	user, err := user.Current()
 
@@ -24,3 +43,70 @@ func main() {
 	// REPLを開始する
 	repl.Start(os.Stdin, os.Stdout)
 }
+
+/**
+ * 関数名: run
+ * 処理: ファイルをプログラムとして実行する
+ * 引数: ファイルのパス
+ * 戻値: 終了コード
+ */
+func run(path string) int {
+
+	env := object.NewEnvironment()
+
+	result := evaluator.DefaultLoader.Run(path, env)
+
+	if errObj, ok := result.(*object.Error); ok {
+		fmt.Fprintln(os.Stderr, errObj.Inspect())
+		return 1
+	}
+
+	return 0
+}
+
+/**
+ * 関数名: check
+ * 処理: ファイルを構文解析して型を検査し、誤りを "ファイル:行:列: メッセージ" の形式で表示する
+ * 引数: ファイルのパスのリスト
+ * 戻値: 終了コード : 誤りが無ければ 0
+ */
+func check(paths []string) int {
+
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: monkey check FILE...")
+		return 2
+	}
+
+	status := 0
+
+	for _, path := range paths {
+
+		source, err := os.ReadFile(path)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+
+		p := parser.New(lexer.New(string(source)))
+		program := p.ParseProgram()
+
+		if len(p.Errors()) != 0 {
+
+			for _, msg := range p.Errors() {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, msg)
+			}
+
+			status = 1
+			continue
+		}
+
+		for _, e := range checker.Check(program) {
+			fmt.Fprintf(os.Stderr, "%s:%s\n", path, e)
+			status = 1
+		}
+	}
+
+	return status
+}
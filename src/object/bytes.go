@@ -0,0 +1,66 @@
+/**
+ * パッケージ名: object
+ * ファイル名: bytes.go
+ * 概要: バイト列オブジェクトを定義する
+ */
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+)
+
+// バイト列オブジェクトを表す構造体
+// .. 生成した後は内容を変更しないので、ハッシュキーとして使える
+type Bytes struct {
+	Value []byte
+}
+
+func (b *Bytes) Type() ObjectType {
+	return BYTES_OBJ
+}
+
+// b"..." の形式で返す。表示できない文字は \xNN の形式で表す
+func (b *Bytes) Inspect() string {
+
+	var out bytes.Buffer
+
+	out.WriteString(`b"`)
+
+	for _, c := range b.Value {
+
+		switch {
+		case c == '\n':
+			out.WriteString(`\n`)
+		case c == '\r':
+			out.WriteString(`\r`)
+		case c == '\t':
+			out.WriteString(`\t`)
+		case c == '\\' || c == '"':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		case c >= 0x20 && c < 0x7f:
+			out.WriteByte(c)
+		default:
+			fmt.Fprintf(&out, `\x%02x`, c)
+		}
+	}
+
+	out.WriteString(`"`)
+
+	return out.String()
+}
+
+func (b *Bytes) Equals(other Object) bool {
+	o, ok := other.(*Bytes)
+	return ok && bytes.Equal(b.Value, o.Value)
+}
+
+func (b *Bytes) HashKey() HashKey {
+
+	h := fnv.New64a()
+	h.Write(b.Value)
+
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
@@ -0,0 +1,87 @@
+/**
+ * パッケージ名: object
+ * ファイル名: cycle.go
+ * 概要: 自分自身を含むオブジェクトを、表示・比較するときの循環の検出を定義する
+ *  ハッシュの値に自分自身を入れるなど、要素を辿ると同じオブジェクトへ戻ってくる場合でも、
+ *  無限に再帰せずに表示・比較できるようにする。
+ */
+package object
+
+// 表示の途中にあるオブジェクト
+type visiting map[Object]bool
+
+// 要素を持つオブジェクト ( 配列・ハッシュ・セット・タプル・インスタンス・列挙型の値 )
+type container interface {
+	Object
+	inspect(seen visiting) string // 表示の途中にあるオブジェクトを引き継いで表示する
+	placeholder() string          // 循環したときに、要素の代わりに表示する文字列
+}
+
+/**
+ * 名前: inspectContainer
+ * 処理: 要素を持つオブジェクトを、循環を検出しながら表示する
+ *  既に表示の途中にあるオブジェクトへ戻った場合は、[...] や {...} を表示する
+ * 引数: オブジェクト, 表示の途中にあるオブジェクト
+ * 戻り値: string
+ */
+func inspectContainer(c container, seen visiting) string {
+
+	if seen[c] {
+		return c.placeholder()
+	}
+
+	seen[c] = true
+	defer delete(seen, c)
+
+	return c.inspect(seen)
+}
+
+/**
+ * 名前: reprIn
+ * 処理: 要素のデバッグ用の表現を返す。要素を持つ場合は、表示の途中にあるオブジェクトを引き継ぐ
+ * 引数: オブジェクト, 表示の途中にあるオブジェクト
+ * 戻り値: string
+ */
+func reprIn(obj Object, seen visiting) string {
+
+	if c, ok := obj.(container); ok {
+		return inspectContainer(c, seen)
+	}
+
+	return Repr(obj)
+}
+
+// 比較の途中にあるオブジェクトの組
+type comparing map[[2]Object]bool
+
+// 要素を辿って比較するオブジェクト
+type deepEquatable interface {
+	equals(other Object, seen comparing) bool
+}
+
+/**
+ * 名前: equalIn
+ * 処理: 2つのオブジェクトを、循環を検出しながら比較する
+ *  既に比較の途中にある組へ戻った場合は、等しいとみなして比較を続ける
+ *  ( 他の要素が異なれば、最終的に等しくないと判定される )
+ * 引数: オブジェクト, オブジェクト, 比較の途中にあるオブジェクトの組
+ * 戻り値: bool
+ */
+func equalIn(a, b Object, seen comparing) bool {
+
+	d, ok := a.(deepEquatable)
+
+	if !ok {
+		return Equal(a, b)
+	}
+
+	pair := [2]Object{a, b}
+
+	if seen[pair] {
+		return true
+	}
+
+	seen[pair] = true
+
+	return d.equals(b, seen)
+}
@@ -11,14 +11,31 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 	return env
 }
 
+// 関数の呼び出しごとに作る環境を返す。defer文で登録した呼び出しはこの環境が保持する
+func NewFrameEnvironment(outer *Environment) *Environment {
+	env := NewEnclosedEnvironment(outer)
+	env.frame = true
+	return env
+}
+
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s}
+	c := make(map[string]bool)
+	return &Environment{store: s, consts: c}
 }
 
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store    map[string]Object
+	consts   map[string]bool // const で束縛された名前
+	outer    *Environment
+	frame    bool           // 関数の呼び出しごとに作った環境であれば true
+	deferred []DeferredCall // defer文で登録した呼び出し ( 登録した順 )
+}
+
+// defer文で登録した、関数を抜けるときに実行する呼び出し
+type DeferredCall struct {
+	Function  Object   // 呼び出す関数
+	Arguments []Object // defer文を評価した時点の引数
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
@@ -35,3 +52,55 @@ func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
 }
+
+// const で名前を束縛する。束縛した名前には再代入できない
+func (e *Environment) SetConst(name string, val Object) Object {
+	e.store[name] = val
+	e.consts[name] = true
+	return val
+}
+
+// この環境 ( 外側の環境は含まない ) で名前がconstとして束縛されていればtrueを返す
+func (e *Environment) IsConst(name string) bool {
+	return e.consts[name]
+}
+
+// 名前を束縛している環境を内側から外側に向かって探す。見つからなければnilを返す
+func (e *Environment) Resolve(name string) *Environment {
+
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			return env
+		}
+	}
+
+	return nil
+}
+
+// 最も内側の関数の環境に、関数を抜けるときに実行する呼び出しを登録する
+// 関数の外 ( トップレベル ) で登録しようとした場合はfalseを返す
+func (e *Environment) Defer(call DeferredCall) bool {
+
+	for env := e; env != nil; env = env.outer {
+		if env.frame {
+			env.deferred = append(env.deferred, call)
+			return true
+		}
+	}
+
+	return false
+}
+
+// 登録した呼び出しを、登録とは逆の順 ( LIFO ) で取り出す。登録した呼び出しは空になる
+func (e *Environment) TakeDeferred() []DeferredCall {
+
+	calls := make([]DeferredCall, 0, len(e.deferred))
+
+	for i := len(e.deferred) - 1; i >= 0; i-- {
+		calls = append(calls, e.deferred[i])
+	}
+
+	e.deferred = nil
+
+	return calls
+}
@@ -0,0 +1,238 @@
+/**
+ * パッケージ名: object
+ * ファイル名: hamt.go
+ * 概要: ハッシュの索引に使う HAMT ( Hash Array Mapped Trie ) を定義する
+ *  ハッシュキーの値を 5 ビットずつ区切って木を辿り、キーからペアの位置を引く。
+ *  更新では根から該当する節までの経路だけを複製し、元の木は書き換えない。
+ */
+package object
+
+import "math/bits"
+
+// HAMT の節
+// .. bitmap の立っているビットに対応する項目だけを、詰めて entries に持つ
+type hamtNode struct {
+	bitmap  uint32
+	entries []hamtEntry
+}
+
+// HAMT の節の項目
+// .. node が nil でなければ子の節、nil であれば同じハッシュキーの値を持つキーの集まり ( バケット )
+type hamtEntry struct {
+	node  *hamtNode
+	hash  uint64
+	items []hamtItem
+}
+
+// バケットに入るキーと、そのペアの位置
+type hamtItem struct {
+	key   Object
+	index int
+}
+
+// ハッシュキーの値から、shift の段で使う位置のビットを返す
+func hamtBit(hash uint64, shift uint) uint32 {
+	return 1 << ((hash >> shift) & vectorMask)
+}
+
+// bit に対応する項目の entries での位置を返す
+func (n *hamtNode) position(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+/**
+ * 名前: hamtNode.find
+ * 処理: キーに対応するペアの位置を返す。バケットの中ではキーを Equal で比較する
+ * 引数: ハッシュキーの値, キー, 段のビット位置
+ * 戻り値: 位置, キーが存在するかどうか
+ */
+func (n *hamtNode) find(hash uint64, key Object, shift uint) (int, bool) {
+
+	for n != nil {
+
+		bit := hamtBit(hash, shift)
+
+		if n.bitmap&bit == 0 {
+			return 0, false
+		}
+
+		entry := n.entries[n.position(bit)]
+
+		if entry.node == nil {
+
+			if entry.hash != hash {
+				return 0, false
+			}
+
+			for _, item := range entry.items {
+				if Equal(item.key, key) {
+					return item.index, true
+				}
+			}
+
+			return 0, false
+		}
+
+		n = entry.node
+		shift += vectorBits
+	}
+
+	return 0, false
+}
+
+/**
+ * 名前: hamtNode.insert
+ * 処理: キーとペアの位置を加えた節を返す。キーが既にある場合は位置を置き換える
+ * 引数: ハッシュキーの値, キー, ペアの位置, 段のビット位置
+ * 戻り値: *hamtNode
+ */
+func (n *hamtNode) insert(hash uint64, key Object, index int, shift uint) *hamtNode {
+
+	if n == nil {
+		n = &hamtNode{}
+	}
+
+	bit := hamtBit(hash, shift)
+	pos := n.position(bit)
+
+	// 空いている位置には、新しいバケットを入れる
+	if n.bitmap&bit == 0 {
+
+		entries := make([]hamtEntry, 0, len(n.entries)+1)
+		entries = append(entries, n.entries[:pos]...)
+		entries = append(entries, hamtEntry{hash: hash, items: []hamtItem{{key: key, index: index}}})
+		entries = append(entries, n.entries[pos:]...)
+
+		return &hamtNode{bitmap: n.bitmap | bit, entries: entries}
+	}
+
+	entries := make([]hamtEntry, len(n.entries))
+	copy(entries, n.entries)
+
+	entry := entries[pos]
+
+	switch {
+	case entry.node != nil:
+		entries[pos] = hamtEntry{node: entry.node.insert(hash, key, index, shift+vectorBits)}
+
+	case entry.hash == hash:
+		entries[pos] = hamtEntry{hash: hash, items: insertHamtItem(entry.items, key, index)}
+
+	default:
+		// ハッシュキーの値が異なるバケットとは、次の段以降で分ける
+		added := hamtEntry{hash: hash, items: []hamtItem{{key: key, index: index}}}
+		entries[pos] = hamtEntry{node: mergeHamtEntries(entry, added, shift+vectorBits)}
+	}
+
+	return &hamtNode{bitmap: n.bitmap, entries: entries}
+}
+
+// ハッシュキーの値が異なる2つのバケットを、位置が分かれる段まで下ろした節を返す
+func mergeHamtEntries(a hamtEntry, b hamtEntry, shift uint) *hamtNode {
+
+	bitA := hamtBit(a.hash, shift)
+	bitB := hamtBit(b.hash, shift)
+
+	if bitA == bitB {
+		return &hamtNode{bitmap: bitA, entries: []hamtEntry{{node: mergeHamtEntries(a, b, shift+vectorBits)}}}
+	}
+
+	if bitA > bitB {
+		a, b = b, a
+	}
+
+	return &hamtNode{bitmap: bitA | bitB, entries: []hamtEntry{a, b}}
+}
+
+// バケットにキーを加えたバケットを返す
+func insertHamtItem(items []hamtItem, key Object, index int) []hamtItem {
+
+	out := make([]hamtItem, len(items), len(items)+1)
+	copy(out, items)
+
+	for i, item := range out {
+		if Equal(item.key, key) {
+			out[i].index = index
+			return out
+		}
+	}
+
+	return append(out, hamtItem{key: key, index: index})
+}
+
+/**
+ * 名前: hamtNode.remove
+ * 処理: キーを取り除いた節を返す。節が空になった場合は nil を返す
+ * 引数: ハッシュキーの値, キー, 段のビット位置
+ * 戻り値: *hamtNode
+ */
+func (n *hamtNode) remove(hash uint64, key Object, shift uint) *hamtNode {
+
+	if n == nil {
+		return nil
+	}
+
+	bit := hamtBit(hash, shift)
+
+	if n.bitmap&bit == 0 {
+		return n
+	}
+
+	pos := n.position(bit)
+	entry := n.entries[pos]
+
+	var replaced hamtEntry
+
+	if entry.node != nil {
+
+		child := entry.node.remove(hash, key, shift+vectorBits)
+
+		if child == entry.node {
+			return n
+		}
+
+		replaced = hamtEntry{node: child}
+
+	} else {
+
+		if entry.hash != hash {
+			return n
+		}
+
+		items := make([]hamtItem, 0, len(entry.items))
+
+		for _, item := range entry.items {
+			if !Equal(item.key, key) {
+				items = append(items, item)
+			}
+		}
+
+		if len(items) == len(entry.items) {
+			return n
+		}
+
+		if len(items) > 0 {
+			replaced = hamtEntry{hash: hash, items: items}
+		}
+	}
+
+	// 子の節もバケットも空になった項目は、節から外す
+	if replaced.node == nil && replaced.items == nil {
+
+		if len(n.entries) == 1 {
+			return nil
+		}
+
+		entries := make([]hamtEntry, 0, len(n.entries)-1)
+		entries = append(entries, n.entries[:pos]...)
+		entries = append(entries, n.entries[pos+1:]...)
+
+		return &hamtNode{bitmap: n.bitmap &^ bit, entries: entries}
+	}
+
+	entries := make([]hamtEntry, len(n.entries))
+	copy(entries, n.entries)
+	entries[pos] = replaced
+
+	return &hamtNode{bitmap: n.bitmap, entries: entries}
+}
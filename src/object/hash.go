@@ -0,0 +1,279 @@
+/**
+ * パッケージ名: object
+ * ファイル名: hash.go
+ * 概要: ハッシュオブジェクトを定義する
+ */
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// キーからハッシュキーを求める関数
+// .. 既定ではキーの HashKey を使う。テストで衝突を起こすために差し替えられる
+type HashFunc func(key Hashable) HashKey
+
+// ハッシュキーの既定の求め方
+func defaultHashFunc(key Hashable) HashKey {
+	return key.HashKey()
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// ハッシュオブジェクトを表す構造体
+// .. ペアは追加した順に永続ベクタへ保持し、Inspect や keys, for-in もその順に並べる
+// .. キーからペアの位置を HAMT で引き、同じハッシュキーを持つキーは Equal で比較する
+// .. そのため異なるキーのハッシュキーが衝突しても、互いに上書きしない
+// .. With と Without は元のハッシュを変えずに、構造の大部分を共有した新しいハッシュを返す
+type Hash struct {
+	pairs  *vector[*HashPair] // ペア ( 追加した順 ) : 取り除いたペアの位置は nil
+	index  *hamtNode          // キーからペアの位置を引く索引
+	size   int                // ペアの数
+	hash   HashFunc           // ハッシュキーの求め方 : nil の場合は既定の求め方
+	origin Object             // このハッシュの元になったオブジェクト : With や Without で作ったハッシュには引き継がない
+}
+
+/**
+ * 名前: NewHash
+ * 処理: 空のハッシュを生成する
+ * 引数: なし
+ * 戻り値: *Hash
+ */
+func NewHash() *Hash {
+	return &Hash{}
+}
+
+/**
+ * 名前: NewHashWithFunc
+ * 処理: ハッシュキーの求め方を指定して、空のハッシュを生成する
+ * 引数: ハッシュキーを求める関数
+ * 戻り値: *Hash
+ */
+func NewHashWithFunc(hash HashFunc) *Hash {
+	return &Hash{hash: hash}
+}
+
+func (h *Hash) Type() ObjectType {
+	return HASH_OBJ
+}
+
+func (h *Hash) Inspect() string {
+	return inspectContainer(h, visiting{})
+}
+
+func (h *Hash) inspect(seen visiting) string {
+
+	var out bytes.Buffer
+
+	pairs := []string{}
+
+	for _, pair := range h.Pairs() {
+
+		pairs = append(pairs, fmt.Sprintf(
+			"%s: %s",
+			reprIn(pair.Key, seen),
+			reprIn(pair.Value, seen),
+		))
+
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (h *Hash) placeholder() string {
+	return "{...}"
+}
+
+// キーのハッシュキーの値を求める
+func (h *Hash) hashKey(key Hashable) uint64 {
+
+	if h.hash == nil {
+		return defaultHashFunc(key).Value
+	}
+
+	return h.hash(key).Value
+}
+
+/**
+ * 名前: Hash.Get
+ * 処理: キーに対応するペアを返す
+ * 引数: キー
+ * 戻り値: HashPair, キーが存在するかどうか
+ */
+func (h *Hash) Get(key Hashable) (HashPair, bool) {
+
+	if i, ok := h.index.find(h.hashKey(key), key, 0); ok {
+		return *h.pairs.Get(i), true
+	}
+
+	return HashPair{}, false
+}
+
+/**
+ * 名前: Hash.Set
+ * 処理: キーと値のペアを末尾に追加する
+ *  キーが既に存在する場合は、位置を変えずに値を上書きする
+ *  ハッシュを組み立てるときに使い、このハッシュを共有している他の値には影響しない
+ * 引数: キー, 値
+ * 戻り値: なし
+ */
+func (h *Hash) Set(key Hashable, value Object) {
+	*h = *h.With(key, value)
+}
+
+/**
+ * 名前: Hash.With
+ * 処理: キーと値のペアを加えたハッシュを返す。元のハッシュは変えない
+ *  キーが既に存在する場合は、位置を変えずに値を置き換える
+ * 引数: キー, 値
+ * 戻り値: *Hash
+ */
+func (h *Hash) With(key Hashable, value Object) *Hash {
+
+	pair := &HashPair{Key: key, Value: value}
+	hashed := h.hashKey(key)
+
+	if i, ok := h.index.find(hashed, key, 0); ok {
+		return &Hash{pairs: h.pairs.Set(i, pair), index: h.index, size: h.size, hash: h.hash}
+	}
+
+	pairs := h.pairs
+
+	if pairs == nil {
+		pairs = newVector[*HashPair](nil)
+	}
+
+	return &Hash{
+		pairs: pairs.Push(pair),
+		index: h.index.insert(hashed, key, pairs.Len(), 0),
+		size:  h.size + 1,
+		hash:  h.hash,
+	}
+}
+
+/**
+ * 名前: Hash.Without
+ * 処理: キーのペアを取り除いたハッシュを返す。元のハッシュは変えない
+ * 引数: キー
+ * 戻り値: *Hash
+ */
+func (h *Hash) Without(key Hashable) *Hash {
+
+	hashed := h.hashKey(key)
+
+	i, ok := h.index.find(hashed, key, 0)
+
+	if !ok {
+		return &Hash{pairs: h.pairs, index: h.index, size: h.size, hash: h.hash}
+	}
+
+	out := &Hash{
+		pairs: h.pairs.Set(i, nil),
+		index: h.index.remove(hashed, key, 0),
+		size:  h.size - 1,
+		hash:  h.hash,
+	}
+
+	// 取り除いた位置が残っているペアより多くなったら、詰め直す
+	if removed := out.pairs.Len() - out.size; removed > vectorWidth && removed > out.size {
+
+		compacted := &Hash{hash: h.hash}
+
+		for _, pair := range out.Pairs() {
+			compacted.Set(pair.Key.(Hashable), pair.Value)
+		}
+
+		return compacted
+	}
+
+	return out
+}
+
+/**
+ * 名前: Hash.SetOrigin
+ * 処理: このハッシュの元になったオブジェクトを記録する
+ *  捕捉したエラーを表すハッシュに元のエラーを持たせ、送出し直すときに使う
+ *  利用者から見えるキーや値とは別に持つので、同じキーを持つハッシュと取り違えない
+ * 引数: 元になったオブジェクト
+ * 戻り値: なし
+ */
+func (h *Hash) SetOrigin(origin Object) {
+	h.origin = origin
+}
+
+/**
+ * 名前: Hash.Origin
+ * 処理: このハッシュの元になったオブジェクトを返す
+ * 引数: なし
+ * 戻り値: 元になったオブジェクト : 記録していない場合は nil
+ */
+func (h *Hash) Origin() Object {
+	return h.origin
+}
+
+/**
+ * 名前: Hash.Len
+ * 処理: ペアの数を返す
+ * 引数: なし
+ * 戻り値: int
+ */
+func (h *Hash) Len() int {
+	return h.size
+}
+
+/**
+ * 名前: Hash.Pairs
+ * 処理: すべてのペアを追加した順に返す
+ * 引数: なし
+ * 戻り値: []HashPair
+ */
+func (h *Hash) Pairs() []HashPair {
+
+	pairs := make([]HashPair, 0, h.size)
+
+	if h.pairs == nil {
+		return pairs
+	}
+
+	for _, pair := range h.pairs.Slice(0) {
+		if pair != nil {
+			pairs = append(pairs, *pair)
+		}
+	}
+
+	return pairs
+}
+
+// ペアの数が同じで、同じキーに等しい値を持っていればtrueを返す
+func (h *Hash) Equals(other Object) bool {
+	return equalIn(h, other, comparing{})
+}
+
+func (h *Hash) equals(other Object, seen comparing) bool {
+
+	o, ok := other.(*Hash)
+
+	if !ok || h.Len() != o.Len() {
+		return false
+	}
+
+	for _, pair := range h.Pairs() {
+
+		otherPair, ok := o.Get(pair.Key.(Hashable))
+
+		if !ok || !equalIn(pair.Value, otherPair.Value, seen) {
+			return false
+		}
+	}
+
+	return true
+}
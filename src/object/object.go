@@ -9,7 +9,9 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/MasaruFukazawa/monkey-lang/src/ast"
+	"github.com/MasaruFukazawa/monkey-lang/src/token"
 	"hash/fnv"
+	"strconv"
 	"strings"
 )
 
@@ -27,24 +29,114 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	MODULE_OBJ       = "MODULE"
+	STRUCT_OBJ       = "STRUCT"
+	INSTANCE_OBJ     = "INSTANCE"
+	ENUM_OBJ         = "ENUM"
+	ENUM_VALUE_OBJ   = "ENUM_VALUE"
+	VARIANT_OBJ      = "VARIANT"
+	SET_OBJ          = "SET"
+	TUPLE_OBJ        = "TUPLE"
+	BYTES_OBJ        = "BYTES"
 )
 
 // オブジェクトの種類を定義する
+// .. Inspect は puts などで利用者に見せる表示用の表現を返す
 type Object interface {
 	Type() ObjectType
 	Inspect() string
 }
 
+// オブジェクトが表示用とは別に、デバッグ用の表現を持つことを示すインターフェース
+// .. REPL の結果と、配列やハッシュなどの要素の表示に使う
+type Representable interface {
+	Repr() string
+}
+
+/**
+ * 名前: Repr
+ * 処理: オブジェクトのデバッグ用の表現を返す
+ *  Repr を持たないオブジェクトは、表示用の表現 ( Inspect ) をそのまま返す
+ * 引数: オブジェクト
+ * 戻り値: string
+ */
+func Repr(obj Object) string {
+
+	if r, ok := obj.(Representable); ok {
+		return r.Repr()
+	}
+
+	return obj.Inspect()
+}
+
 // オブジェクトがハッシュキーとして使えることを示すインターフェース
 type Hashable interface {
+	Object
 	HashKey() HashKey
 }
 
+// オブジェクトが値として比較できることを示すインターフェース
+// .. == と != 、配列の contains 、match のリテラルパターンで使う
+type Equatable interface {
+	Equals(other Object) bool
+}
+
+/**
+ * 名前: Equal
+ * 処理: 2つのオブジェクトが等しいかどうかを判定する
+ *  Equatable を実装していればその値で、そうでなければ同一性で比較する
+ * 引数: オブジェクト, オブジェクト
+ * 戻り値: bool
+ */
+func Equal(a, b Object) bool {
+
+	if eq, ok := a.(Equatable); ok {
+		return eq.Equals(b)
+	}
+
+	return a == b
+}
+
 // 整数オブジェクトを表す構造体
+// .. 生成した後は値を変更しない。そのため小さい整数は NewInteger で同じオブジェクトを使い回す
 type Integer struct {
 	Value int64
 }
 
+// 使い回す小さい整数の範囲
+const (
+	minCachedInteger = -128
+	maxCachedInteger = 1024
+)
+
+// あらかじめ生成しておく小さい整数
+var cachedIntegers = func() []*Integer {
+
+	integers := make([]*Integer, maxCachedInteger-minCachedInteger+1)
+
+	for i := range integers {
+		integers[i] = &Integer{Value: int64(i + minCachedInteger)}
+	}
+
+	return integers
+}()
+
+/**
+ * 名前: NewInteger
+ * 処理: 整数オブジェクトを返す
+ *  -128 から 1024 までの整数は、あらかじめ生成したオブジェクトを返して割り当てを省く
+ * 引数: 値
+ * 戻り値: *Integer
+ */
+func NewInteger(value int64) *Integer {
+
+	if minCachedInteger <= value && value <= maxCachedInteger {
+		return cachedIntegers[value-minCachedInteger]
+	}
+
+	return &Integer{Value: value}
+}
+
 // 整数オブジェクトの種類を返す
 func (i *Integer) Type() ObjectType {
 	return INTEGER_OBJ
@@ -55,6 +147,11 @@ func (i *Integer) Inspect() string {
 	return fmt.Sprintf("%d", i.Value)
 }
 
+func (i *Integer) Equals(other Object) bool {
+	o, ok := other.(*Integer)
+	return ok && i.Value == o.Value
+}
+
 // 真偽値オブジェクトを表す構造体
 type Boolean struct {
 	Value bool
@@ -70,6 +167,11 @@ func (b *Boolean) Inspect() string {
 	return fmt.Sprintf("%t", b.Value)
 }
 
+func (b *Boolean) Equals(other Object) bool {
+	o, ok := other.(*Boolean)
+	return ok && b.Value == o.Value
+}
+
 // NULLオブジェクトを表す構造体
 type Null struct{}
 
@@ -99,8 +201,12 @@ func (rv *ReturnValue) Inspect() string {
 }
 
 // エラーオブジェクトを表す構造体
+// .. try式で捕捉されるまで、評価を中断して呼び出し元へ伝播する
 type Error struct {
-	Message string
+	Message  string         // エラーメッセージ
+	Kind     string         // エラーの種類 ( TypeError など )
+	Position token.Position // エラーが発生した位置
+	Value    Object         // throw文で送出された値 : 実行時エラーの場合は nil
 }
 
 // エラーオブジェクトの種類を返す
@@ -159,6 +265,17 @@ func (s *String) Inspect() string {
 	return s.Value
 }
 
+// 引用符で囲み、引用符や制御文字をエスケープして返す
+// .. ["a, b"] と ["a", "b"] を見分けられるようにする
+func (s *String) Repr() string {
+	return strconv.Quote(s.Value)
+}
+
+func (s *String) Equals(other Object) bool {
+	o, ok := other.(*String)
+	return ok && s.Value == o.Value
+}
+
 // 組み込み関数オブジェクトを表す構造体
 type Builtin struct {
 	Fn BuiltinFunction
@@ -173,8 +290,21 @@ func (b *Builtin) Inspect() string {
 }
 
 // 配列オブジェクトを表す構造体
+// .. 要素は永続ベクタに持ち、push や rest, 要素の置き換えは元の配列を変えずに新しい配列を返す
+// .. 新しい配列は元の配列と木の大部分を共有するので、要素全体を複製しない
 type Array struct {
-	Elements []Object
+	elements *vector[Object] // 要素 : nil の場合は空の配列
+	offset   int             // rest で読み飛ばした先頭の要素の数
+}
+
+/**
+ * 名前: NewArray
+ * 処理: 要素を並べた配列を生成する
+ * 引数: 要素
+ * 戻り値: *Array
+ */
+func NewArray(elements []Object) *Array {
+	return &Array{elements: newVector(elements)}
 }
 
 func (ao *Array) Type() ObjectType {
@@ -182,13 +312,17 @@ func (ao *Array) Type() ObjectType {
 }
 
 func (ao *Array) Inspect() string {
+	return inspectContainer(ao, visiting{})
+}
+
+func (ao *Array) inspect(seen visiting) string {
 
 	var out bytes.Buffer
 
 	elements := []string{}
 
-	for _, e := range ao.Elements {
-		elements = append(elements, e.Inspect())
+	for _, e := range ao.Elements() {
+		elements = append(elements, reprIn(e, seen))
 	}
 
 	out.WriteString("[")
@@ -198,6 +332,112 @@ func (ao *Array) Inspect() string {
 	return out.String()
 }
 
+/**
+ * 名前: Array.Len
+ * 処理: 要素の数を返す
+ * 引数: なし
+ * 戻り値: int
+ */
+func (ao *Array) Len() int {
+
+	if ao.elements == nil {
+		return 0
+	}
+
+	return ao.elements.Len() - ao.offset
+}
+
+/**
+ * 名前: Array.At
+ * 処理: i 番目の要素を返す。i は 0 以上 Len() 未満でなければならない
+ * 引数: 位置
+ * 戻り値: 要素
+ */
+func (ao *Array) At(i int) Object {
+	return ao.elements.Get(ao.offset + i)
+}
+
+/**
+ * 名前: Array.Elements
+ * 処理: すべての要素を新しいスライスに並べて返す
+ * 引数: なし
+ * 戻り値: []Object
+ */
+func (ao *Array) Elements() []Object {
+
+	if ao.elements == nil {
+		return []Object{}
+	}
+
+	return ao.elements.Slice(ao.offset)
+}
+
+/**
+ * 名前: Array.Push
+ * 処理: 末尾に要素を追加した配列を返す
+ * 引数: 要素
+ * 戻り値: *Array
+ */
+func (ao *Array) Push(el Object) *Array {
+
+	if ao.elements == nil {
+		return NewArray([]Object{el})
+	}
+
+	return &Array{elements: ao.elements.Push(el), offset: ao.offset}
+}
+
+/**
+ * 名前: Array.Rest
+ * 処理: 先頭の要素を除いた配列を返す。空の配列では nil を返す
+ * 引数: なし
+ * 戻り値: *Array
+ */
+func (ao *Array) Rest() *Array {
+
+	if ao.Len() == 0 {
+		return nil
+	}
+
+	return &Array{elements: ao.elements, offset: ao.offset + 1}
+}
+
+/**
+ * 名前: Array.With
+ * 処理: i 番目の要素を置き換えた配列を返す。i は 0 以上 Len() 未満でなければならない
+ * 引数: 位置, 要素
+ * 戻り値: *Array
+ */
+func (ao *Array) With(i int, el Object) *Array {
+	return &Array{elements: ao.elements.Set(ao.offset+i, el), offset: ao.offset}
+}
+
+func (ao *Array) placeholder() string {
+	return "[...]"
+}
+
+// 要素の数が同じで、各要素が順に等しければtrueを返す
+func (ao *Array) Equals(other Object) bool {
+	return equalIn(ao, other, comparing{})
+}
+
+func (ao *Array) equals(other Object, seen comparing) bool {
+
+	o, ok := other.(*Array)
+
+	if !ok || ao.Len() != o.Len() {
+		return false
+	}
+
+	for i := 0; i < ao.Len(); i++ {
+		if !equalIn(ao.At(i), o.At(i), seen) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // ハッシュキーオブジェクトを表す構造体
 type HashKey struct {
 	Type  ObjectType
@@ -229,38 +469,226 @@ func (s *String) HashKey() HashKey {
 	return HashKey{Type: s.Type(), Value: h.Sum64()}
 }
 
-type HashPair struct {
-	Key   Object
-	Value Object
+/**
+ * 名前: AsHashable
+ * 処理: オブジェクトをハッシュキーとして使えるかどうかを判定する
+ *  列挙型の値とタプルは、要素がすべてハッシュキーとして使える場合に限り使える
+ * 引数: オブジェクト
+ * 戻り値: Hashable, bool
+ */
+func AsHashable(obj Object) (Hashable, bool) {
+
+	var elements []Object
+
+	switch obj := obj.(type) {
+	case *EnumValue:
+		elements = obj.Payload
+	case *Tuple:
+		elements = obj.Elements
+	}
+
+	for _, el := range elements {
+		if _, ok := AsHashable(el); !ok {
+			return nil, false
+		}
+	}
+
+	hashable, ok := obj.(Hashable)
+
+	return hashable, ok
 }
 
-type Hash struct {
-	Pairs map[HashKey]HashPair
+// モジュールオブジェクトを表す構造体
+// .. import文で読み込んだファイルの、エクスポートされた名前と値を保持する
+type Module struct {
+	Name    string            // ファイル名から拡張子を除いた名前
+	Path    string            // モジュールのファイルのパス
+	Exports map[string]Object // エクスポートされた名前と値
 }
 
-func (h *Hash) Type() ObjectType {
-	return HASH_OBJ
+func (m *Module) Type() ObjectType {
+	return MODULE_OBJ
 }
 
-func (h *Hash) Inspect() string {
+func (m *Module) Inspect() string {
+	return fmt.Sprintf("<module %s>", m.Path)
+}
 
-	var out bytes.Buffer
+// 構造体の型を表す構造体
+// .. struct文で定義し、呼び出すとインスタンスを生成する
+type StructType struct {
+	Name    string               // 構造体の名前
+	Fields  []string             // フィールド名 ( 定義した順 )
+	Methods map[string]*Function // メソッド名とメソッド ( self は束縛していない )
+}
 
-	pairs := []string{}
+func (st *StructType) Type() ObjectType {
+	return STRUCT_OBJ
+}
 
-	for _, pair := range h.Pairs {
+func (st *StructType) Inspect() string {
+	return fmt.Sprintf("<struct %s>", st.Name)
+}
 
-		pairs = append(pairs, fmt.Sprintf(
-			"%s: %s",
-			pair.Key.Inspect(),
-			pair.Value.Inspect(),
-		))
+// 構造体のインスタンスを表す構造体
+type Instance struct {
+	Struct *StructType       // インスタンスの型
+	Fields map[string]Object // フィールド名と値
+}
 
+func (i *Instance) Type() ObjectType {
+	return INSTANCE_OBJ
+}
+
+func (i *Instance) Inspect() string {
+	return inspectContainer(i, visiting{})
+}
+
+func (i *Instance) inspect(seen visiting) string {
+
+	var out bytes.Buffer
+
+	fields := []string{}
+
+	for _, name := range i.Struct.Fields {
+		fields = append(fields, fmt.Sprintf("%s: %s", name, reprIn(i.Fields[name], seen)))
 	}
 
+	out.WriteString(i.Struct.Name)
 	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString(strings.Join(fields, ", "))
 	out.WriteString("}")
 
 	return out.String()
 }
+
+func (i *Instance) placeholder() string {
+	return i.Struct.Name + "{...}"
+}
+
+// 列挙型を表す構造体
+// .. enum文で定義し、Status.Pending や Status.Done(1) でバリアントの値を生成する
+type EnumType struct {
+	Name     string         // 列挙型の名前
+	Variants []*EnumVariant // バリアント ( 定義した順 )
+}
+
+// 列挙型のバリアントを表す構造体
+type EnumVariant struct {
+	Name   string   // バリアント名
+	Fields []string // ペイロードのフィールド名 : ペイロードを持たない場合は空
+}
+
+func (et *EnumType) Type() ObjectType {
+	return ENUM_OBJ
+}
+
+func (et *EnumType) Inspect() string {
+	return fmt.Sprintf("<enum %s>", et.Name)
+}
+
+// 名前に対応するバリアントを返す
+func (et *EnumType) Variant(name string) (*EnumVariant, bool) {
+
+	for _, v := range et.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
+// ペイロードを持つバリアントを表す構造体
+// .. Status.Done のように参照すると得られ、呼び出すとペイロードを持つ値を生成する
+type VariantConstructor struct {
+	Enum    *EnumType    // バリアントの列挙型
+	Variant *EnumVariant // 生成する値のバリアント
+}
+
+func (vc *VariantConstructor) Type() ObjectType {
+	return VARIANT_OBJ
+}
+
+func (vc *VariantConstructor) Inspect() string {
+	return fmt.Sprintf("<variant %s.%s>", vc.Enum.Name, vc.Variant.Name)
+}
+
+// 列挙型の値を表す構造体
+type EnumValue struct {
+	Enum    *EnumType    // 値の列挙型
+	Variant *EnumVariant // 値のバリアント
+	Payload []Object     // ペイロード ( フィールドの順 )
+}
+
+func (ev *EnumValue) Type() ObjectType {
+	return ENUM_VALUE_OBJ
+}
+
+func (ev *EnumValue) Inspect() string {
+	return inspectContainer(ev, visiting{})
+}
+
+func (ev *EnumValue) inspect(seen visiting) string {
+
+	var out bytes.Buffer
+
+	out.WriteString(ev.Enum.Name + "." + ev.Variant.Name)
+
+	if len(ev.Payload) > 0 {
+
+		payload := []string{}
+
+		for _, el := range ev.Payload {
+			payload = append(payload, reprIn(el, seen))
+		}
+
+		out.WriteString("(" + strings.Join(payload, ", ") + ")")
+	}
+
+	return out.String()
+}
+
+func (ev *EnumValue) placeholder() string {
+	return ev.Enum.Name + "." + ev.Variant.Name + "(...)"
+}
+
+// 同じ列挙型の同じバリアントで、ペイロードが等しければtrueを返す
+func (ev *EnumValue) Equals(other Object) bool {
+	return equalIn(ev, other, comparing{})
+}
+
+func (ev *EnumValue) equals(other Object, seen comparing) bool {
+
+	o, ok := other.(*EnumValue)
+
+	if !ok || ev.Enum != o.Enum || ev.Variant != o.Variant {
+		return false
+	}
+
+	for i, el := range ev.Payload {
+		if !equalIn(el, o.Payload[i], seen) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// 列挙型の名前・バリアント名・ペイロードのハッシュキーからハッシュキーを求める
+// .. ペイロードがハッシュキーとして使えるかどうかは AsHashable で判定する
+func (ev *EnumValue) HashKey() HashKey {
+
+	h := fnv.New64a()
+	h.Write([]byte(ev.Enum.Name + "." + ev.Variant.Name))
+
+	for _, el := range ev.Payload {
+
+		if hashable, ok := el.(Hashable); ok {
+			key := hashable.HashKey()
+			fmt.Fprintf(h, "|%s:%d", key.Type, key.Value)
+		}
+	}
+
+	return HashKey{Type: ev.Type(), Value: h.Sum64()}
+}
@@ -25,3 +25,557 @@ func TestStringHashKey(t *testing.T) {
 	}
 
 }
+
+func TestEqual(t *testing.T) {
+
+	tests := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{&Integer{Value: 1}, &Integer{Value: 1}, true},
+		{&Integer{Value: 1}, &String{Value: "1"}, false},
+		{&String{Value: "a"}, &String{Value: "a"}, true},
+		{&Boolean{Value: true}, &Boolean{Value: false}, false},
+		{
+			NewArray([]Object{&Integer{Value: 1}, NewArray([]Object{&String{Value: "x"}})}),
+			NewArray([]Object{&Integer{Value: 1}, NewArray([]Object{&String{Value: "x"}})}),
+			true,
+		},
+		{
+			NewArray([]Object{&Integer{Value: 1}}),
+			NewArray([]Object{&Integer{Value: 1}, &Integer{Value: 2}}),
+			false,
+		},
+		{
+			hashOf(&String{Value: "a"}, &Integer{Value: 1}),
+			hashOf(&String{Value: "a"}, &Integer{Value: 1}),
+			true,
+		},
+		{
+			hashOf(&String{Value: "a"}, &Integer{Value: 1}),
+			NewHash(),
+			false,
+		},
+		{&Builtin{}, &Builtin{}, false},
+		{NewSet(&Integer{Value: 1}, &Integer{Value: 2}), NewSet(&Integer{Value: 2}, &Integer{Value: 1}), true},
+		{NewSet(&Integer{Value: 1}), NewSet(&Integer{Value: 1}, &Integer{Value: 2}), false},
+		{NewSet(&String{Value: "1"}), NewSet(&Integer{Value: 1}), false},
+		{&Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}, &Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}, true},
+		{&Tuple{Elements: []Object{&Integer{Value: 1}}}, NewArray([]Object{&Integer{Value: 1}}), false},
+		{&Bytes{Value: []byte("ab")}, &Bytes{Value: []byte("ab")}, true},
+		{&Bytes{Value: []byte("ab")}, &String{Value: "ab"}, false},
+	}
+
+	for i, tt := range tests {
+
+		if got := Equal(tt.a, tt.b); got != tt.expected {
+			t.Errorf("tests[%d] - Equal(%s, %s) wrong. expected=%t, got=%t", i, tt.a.Inspect(), tt.b.Inspect(), tt.expected, got)
+		}
+
+		if got := Equal(tt.b, tt.a); got != tt.expected {
+			t.Errorf("tests[%d] - Equal(%s, %s) wrong. expected=%t, got=%t", i, tt.b.Inspect(), tt.a.Inspect(), tt.expected, got)
+		}
+	}
+}
+
+// キーと値を交互に並べて、ハッシュを生成する
+func hashOf(kv ...Object) *Hash {
+
+	h := NewHash()
+
+	for i := 0; i < len(kv); i += 2 {
+		h.Set(kv[i].(Hashable), kv[i+1])
+	}
+
+	return h
+}
+
+func TestHashCollisions(t *testing.T) {
+
+	// すべてのキーが同じハッシュキーになる関数で、衝突を起こす
+	collide := func(key Hashable) HashKey {
+		return HashKey{Type: STRING_OBJ, Value: 42}
+	}
+
+	h := NewHashWithFunc(collide)
+
+	a := &String{Value: "a"}
+	b := &String{Value: "b"}
+
+	h.Set(a, &Integer{Value: 1})
+	h.Set(b, &Integer{Value: 2})
+
+	if h.Len() != 2 {
+		t.Fatalf("colliding keys overwrote each other. len=%d", h.Len())
+	}
+
+	tests := []struct {
+		key      Hashable
+		expected int64
+	}{
+		{a, 1},
+		{b, 2},
+		{&String{Value: "a"}, 1},
+	}
+
+	for _, tt := range tests {
+
+		pair, ok := h.Get(tt.key)
+
+		if !ok {
+			t.Fatalf("no pair for key %s", tt.key.Inspect())
+		}
+
+		if pair.Value.(*Integer).Value != tt.expected {
+			t.Errorf("wrong value for key %s. expected=%d, got=%s", tt.key.Inspect(), tt.expected, pair.Value.Inspect())
+		}
+	}
+
+	if _, ok := h.Get(&String{Value: "c"}); ok {
+		t.Errorf("found pair for colliding key that was never set")
+	}
+
+	h.Set(&String{Value: "b"}, &Integer{Value: 3})
+
+	if h.Len() != 2 {
+		t.Errorf("overwriting a key changed len. len=%d", h.Len())
+	}
+
+	if pair, _ := h.Get(b); pair.Value.(*Integer).Value != 3 {
+		t.Errorf("value was not overwritten. got=%s", pair.Value.Inspect())
+	}
+
+	if !h.Equals(hashOf(&String{Value: "a"}, &Integer{Value: 1}, &String{Value: "b"}, &Integer{Value: 3})) {
+		t.Errorf("colliding hash is not equal to the same hash with default hashing")
+	}
+
+	// 整数の 1 と文字列の "1" のように、型の異なるキーも区別する
+	mixed := NewHashWithFunc(func(key Hashable) HashKey { return HashKey{} })
+	mixed.Set(&Integer{Value: 1}, &String{Value: "int"})
+	mixed.Set(&String{Value: "1"}, &String{Value: "string"})
+
+	if mixed.Len() != 2 {
+		t.Fatalf("keys of different types overwrote each other. len=%d", mixed.Len())
+	}
+
+	if pair, _ := mixed.Get(&Integer{Value: 1}); pair.Value.Inspect() != "int" {
+		t.Errorf("wrong value for integer key. got=%s", pair.Value.Inspect())
+	}
+}
+
+func TestTupleHashKey(t *testing.T) {
+
+	pair := func(a, b Object) *Tuple {
+		return &Tuple{Elements: []Object{a, b}}
+	}
+
+	one := pair(&Integer{Value: 1}, &String{Value: "a"})
+	same := pair(&Integer{Value: 1}, &String{Value: "a"})
+	swapped := pair(&String{Value: "a"}, &Integer{Value: 1})
+	nested := &Tuple{Elements: []Object{one}}
+
+	if one.HashKey() != same.HashKey() {
+		t.Errorf("tuples with same elements have different hash keys")
+	}
+
+	if one.HashKey() == swapped.HashKey() {
+		t.Errorf("tuples with elements in different order have same hash keys")
+	}
+
+	if one.HashKey() == nested.HashKey() {
+		t.Errorf("tuple and tuple containing it have same hash keys")
+	}
+
+	if _, ok := AsHashable(one); !ok {
+		t.Errorf("tuple of hashable elements is not hashable")
+	}
+
+	if _, ok := AsHashable(pair(&Integer{Value: 1}, &Array{})); ok {
+		t.Errorf("tuple containing an array is hashable")
+	}
+}
+
+func TestBytesInspect(t *testing.T) {
+
+	tests := []struct {
+		value    []byte
+		expected string
+	}{
+		{[]byte("hello"), `b"hello"`},
+		{[]byte{}, `b""`},
+		{[]byte{0, 0x7f, 0xff}, `b"\x00\x7f\xff"`},
+		{[]byte("a\"b\\c\n\t\r"), `b"a\"b\\c\n\t\r"`},
+	}
+
+	for _, tt := range tests {
+
+		b := &Bytes{Value: tt.value}
+
+		if b.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect. expected=%s, got=%s", tt.expected, b.Inspect())
+		}
+	}
+
+	if (&Bytes{Value: []byte("ab")}).HashKey() == (&String{Value: "ab"}).HashKey() {
+		t.Errorf("bytes and string with same content have same hash keys")
+	}
+}
+
+// 木の段が増える境目 ( 32, 1056, 33824 ) をまたぐ要素数で確かめる
+func TestPersistentArray(t *testing.T) {
+
+	for _, n := range []int{0, 1, 31, 32, 33, 1055, 1056, 1057, 33825} {
+
+		arr := NewArray(nil)
+		versions := []*Array{arr}
+
+		for i := 0; i < n; i++ {
+			arr = arr.Push(&Integer{Value: int64(i)})
+			versions = append(versions, arr)
+		}
+
+		if arr.Len() != n {
+			t.Fatalf("wrong length. want=%d, got=%d", n, arr.Len())
+		}
+
+		// push した後も、それまでの版の内容は変わらない
+		for length, version := range versions {
+			if version.Len() != length {
+				t.Fatalf("older version changed. want len=%d, got=%d", length, version.Len())
+			}
+		}
+
+		built := NewArray(arr.Elements())
+
+		for i := 0; i < n; i++ {
+			if arr.At(i).(*Integer).Value != int64(i) || built.At(i).(*Integer).Value != int64(i) {
+				t.Fatalf("wrong element at %d for n=%d", i, n)
+			}
+		}
+
+		if n == 0 {
+			if arr.Rest() != nil {
+				t.Errorf("rest of empty array is not nil")
+			}
+			continue
+		}
+
+		rest := arr.Rest().Push(&Integer{Value: -1})
+		changed := arr.With(n-1, &String{Value: "x"})
+
+		if rest.Len() != n {
+			t.Errorf("wrong rest length. want=%d, got=%d", n, rest.Len())
+		}
+
+		if n > 1 && rest.At(0).(*Integer).Value != 1 {
+			t.Errorf("wrong first element of rest. got=%s", rest.At(0).Inspect())
+		}
+
+		if last := rest.At(n - 1).(*Integer); last.Value != -1 {
+			t.Errorf("wrong last element of rest. got=%d", last.Value)
+		}
+
+		if changed.At(n-1).Inspect() != "x" || arr.At(n-1).Inspect() == "x" {
+			t.Errorf("With did not keep the original array for n=%d", n)
+		}
+	}
+}
+
+func TestPersistentHash(t *testing.T) {
+
+	// ハッシュキーの値の下位ビットだけが同じキーや、まったく同じキーが混ざるようにする
+	hashes := []HashFunc{
+		nil,
+		func(key Hashable) HashKey { return HashKey{Value: key.HashKey().Value % 7} },
+		func(key Hashable) HashKey { return HashKey{Value: key.HashKey().Value << 40} },
+	}
+
+	for _, hash := range hashes {
+
+		h := NewHashWithFunc(hash)
+
+		for i := 0; i < 500; i++ {
+			h.Set(&Integer{Value: int64(i)}, &Integer{Value: int64(i * i)})
+		}
+
+		original := h
+		updated := h.With(&Integer{Value: 3}, &String{Value: "three"}).With(&Integer{Value: 500}, &Boolean{Value: true})
+		removed := h
+
+		for i := 0; i < 500; i += 2 {
+			removed = removed.Without(&Integer{Value: int64(i)})
+		}
+
+		if original.Len() != 500 || updated.Len() != 501 || removed.Len() != 250 {
+			t.Fatalf("wrong lengths. original=%d, updated=%d, removed=%d", original.Len(), updated.Len(), removed.Len())
+		}
+
+		if pair, _ := original.Get(&Integer{Value: 3}); pair.Value.Inspect() != "9" {
+			t.Errorf("With changed the original hash. got=%s", pair.Value.Inspect())
+		}
+
+		if pair, _ := updated.Get(&Integer{Value: 3}); pair.Value.Inspect() != "three" {
+			t.Errorf("wrong updated value. got=%s", pair.Value.Inspect())
+		}
+
+		// 値を置き換えたキーは位置を変えず、追加したキーは末尾に並ぶ
+		pairs := updated.Pairs()
+
+		if pairs[3].Key.Inspect() != "3" || pairs[500].Key.Inspect() != "500" {
+			t.Errorf("wrong order after With. pairs[3]=%s, pairs[500]=%s", pairs[3].Key.Inspect(), pairs[500].Key.Inspect())
+		}
+
+		for i, pair := range removed.Pairs() {
+			if pair.Key.(*Integer).Value != int64(i*2+1) {
+				t.Fatalf("wrong order after Without. pairs[%d]=%s", i, pair.Key.Inspect())
+			}
+		}
+
+		for i := 0; i < 500; i++ {
+
+			_, inOriginal := original.Get(&Integer{Value: int64(i)})
+			_, inRemoved := removed.Get(&Integer{Value: int64(i)})
+
+			if !inOriginal || inRemoved != (i%2 == 1) {
+				t.Fatalf("wrong membership of %d. original=%t, removed=%t", i, inOriginal, inRemoved)
+			}
+		}
+	}
+}
+
+// 以下は永続構造と、要素をすべて複製する以前のやり方との比較
+// .. go test -bench . ./object
+
+const benchmarkSize = 2000
+
+var benchmarkValue = &Boolean{Value: true}
+
+func BenchmarkArrayPush(b *testing.B) {
+
+	for i := 0; i < b.N; i++ {
+
+		arr := NewArray(nil)
+
+		for j := 0; j < benchmarkSize; j++ {
+			arr = arr.Push(benchmarkValue)
+		}
+	}
+}
+
+func BenchmarkArrayPushCopy(b *testing.B) {
+
+	for i := 0; i < b.N; i++ {
+
+		elements := []Object{}
+
+		for j := 0; j < benchmarkSize; j++ {
+			next := make([]Object, len(elements)+1)
+			copy(next, elements)
+			next[len(elements)] = benchmarkValue
+			elements = next
+		}
+	}
+}
+
+func BenchmarkArrayRest(b *testing.B) {
+
+	arr := NewArray(make([]Object, benchmarkSize))
+
+	for i := 0; i < b.N; i++ {
+		for rest := arr; rest.Len() > 0; rest = rest.Rest() {
+		}
+	}
+}
+
+func BenchmarkArrayRestCopy(b *testing.B) {
+
+	elements := make([]Object, benchmarkSize)
+
+	for i := 0; i < b.N; i++ {
+		for rest := elements; len(rest) > 0; {
+			next := make([]Object, len(rest)-1)
+			copy(next, rest[1:])
+			rest = next
+		}
+	}
+}
+
+func BenchmarkArraySet(b *testing.B) {
+
+	arr := NewArray(make([]Object, benchmarkSize))
+
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < benchmarkSize; j++ {
+			arr = arr.With(j, benchmarkValue)
+		}
+	}
+}
+
+func BenchmarkArraySetCopy(b *testing.B) {
+
+	elements := make([]Object, benchmarkSize)
+
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < benchmarkSize; j++ {
+			next := make([]Object, len(elements))
+			copy(next, elements)
+			next[j] = benchmarkValue
+			elements = next
+		}
+	}
+}
+
+func BenchmarkHashWith(b *testing.B) {
+
+	keys := make([]*Integer, benchmarkSize)
+
+	for j := range keys {
+		keys[j] = &Integer{Value: int64(j)}
+	}
+
+	for i := 0; i < b.N; i++ {
+
+		h := NewHash()
+
+		for _, key := range keys {
+			h = h.With(key, benchmarkValue)
+		}
+	}
+}
+
+func BenchmarkHashWithCopy(b *testing.B) {
+
+	keys := make([]*Integer, benchmarkSize)
+
+	for j := range keys {
+		keys[j] = &Integer{Value: int64(j)}
+	}
+
+	for i := 0; i < b.N; i++ {
+
+		pairs := []HashPair{}
+		index := map[HashKey]int{}
+
+		for _, key := range keys {
+
+			nextPairs := make([]HashPair, len(pairs), len(pairs)+1)
+			copy(nextPairs, pairs)
+
+			nextIndex := make(map[HashKey]int, len(index)+1)
+
+			for k, v := range index {
+				nextIndex[k] = v
+			}
+
+			nextIndex[key.HashKey()] = len(nextPairs)
+			pairs = append(nextPairs, HashPair{Key: key, Value: benchmarkValue})
+			index = nextIndex
+		}
+	}
+}
+
+func TestRepr(t *testing.T) {
+
+	tests := []struct {
+		obj     Object
+		inspect string
+		repr    string
+	}{
+		{&String{Value: "a, b"}, `a, b`, `"a, b"`},
+		{&String{Value: "say \"hi\"\n\t日本"}, "say \"hi\"\n\t日本", `"say \"hi\"\n\t日本"`},
+		{&Integer{Value: 1}, `1`, `1`},
+		{NewArray([]Object{&String{Value: "a, b"}}), `["a, b"]`, `["a, b"]`},
+		{NewArray([]Object{&String{Value: "a"}, &String{Value: "b"}}), `["a", "b"]`, `["a", "b"]`},
+		{hashOf(&String{Value: "k"}, NewArray([]Object{&String{Value: ""}})), `{"k": [""]}`, `{"k": [""]}`},
+		{&Tuple{Elements: []Object{&String{Value: "x"}}}, `("x",)`, `("x",)`},
+		{NewSet(&String{Value: "x"}), `#{"x"}`, `#{"x"}`},
+	}
+
+	for _, tt := range tests {
+
+		if tt.obj.Inspect() != tt.inspect {
+			t.Errorf("wrong Inspect. expected=%s, got=%s", tt.inspect, tt.obj.Inspect())
+		}
+
+		if Repr(tt.obj) != tt.repr {
+			t.Errorf("wrong Repr. expected=%s, got=%s", tt.repr, Repr(tt.obj))
+		}
+	}
+}
+
+func TestCycles(t *testing.T) {
+
+	key := &String{Value: "self"}
+
+	// {"self": <自分自身>}
+	self := NewHash()
+	self.Set(key, self)
+
+	// {"items": [<自分自身>, 1]}
+	nested := NewHash()
+	nested.Set(&String{Value: "items"}, NewArray([]Object{nested, &Integer{Value: 1}}))
+
+	node := &StructType{Name: "Node", Fields: []string{"next"}}
+	loop := &Instance{Struct: node, Fields: map[string]Object{}}
+	loop.Fields["next"] = &Instance{Struct: node, Fields: map[string]Object{"next": loop}}
+
+	// 同じオブジェクトが循環せずに2回現れる場合は、省略しない
+	shared := NewArray([]Object{&Integer{Value: 1}})
+	twice := NewArray([]Object{shared, shared})
+
+	tests := []struct {
+		obj      Object
+		expected string
+	}{
+		{self, `{"self": {...}}`},
+		{nested, `{"items": [{...}, 1]}`},
+		{loop, `Node{next: Node{next: Node{...}}}`},
+		{twice, `[[1], [1]]`},
+	}
+
+	for _, tt := range tests {
+		if tt.obj.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect. expected=%s, got=%s", tt.expected, tt.obj.Inspect())
+		}
+	}
+
+	other := NewHash()
+	other.Set(key, other)
+
+	different := NewHash()
+	different.Set(key, different)
+	different.Set(&String{Value: "x"}, &Integer{Value: 1})
+
+	if !Equal(self, other) {
+		t.Errorf("self-referential hashes of the same shape are not equal")
+	}
+
+	if Equal(self, different) || Equal(different, self) {
+		t.Errorf("self-referential hashes of different shapes are equal")
+	}
+
+	if !Equal(nested, nested) {
+		t.Errorf("self-referential hash is not equal to itself")
+	}
+}
+
+func TestNewInteger(t *testing.T) {
+
+	for _, value := range []int64{minCachedInteger, -1, 0, 1, maxCachedInteger} {
+
+		if NewInteger(value) != NewInteger(value) {
+			t.Errorf("small integer %d is not cached", value)
+		}
+
+		if NewInteger(value).Value != value {
+			t.Errorf("wrong cached value. want=%d, got=%d", value, NewInteger(value).Value)
+		}
+	}
+
+	for _, value := range []int64{minCachedInteger - 1, maxCachedInteger + 1, 1 << 40} {
+
+		if NewInteger(value).Value != value {
+			t.Errorf("wrong value. want=%d, got=%d", value, NewInteger(value).Value)
+		}
+	}
+}
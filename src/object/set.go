@@ -0,0 +1,172 @@
+/**
+ * パッケージ名: object
+ * ファイル名: set.go
+ * 概要: セットオブジェクトを定義する
+ */
+package object
+
+import (
+	"strings"
+)
+
+// セットオブジェクトを表す構造体
+// .. ハッシュキーとして使える値を重複なく、追加した順に保持する
+// .. 要素は、要素自身をキーとするハッシュに入れる
+type Set struct {
+	elements *Hash
+}
+
+/**
+ * 名前: NewSet
+ * 処理: 要素を追加したセットを生成する
+ * 引数: 要素 ( 重複する要素は1つにまとめる )
+ * 戻り値: *Set
+ */
+func NewSet(elements ...Hashable) *Set {
+
+	s := &Set{elements: NewHash()}
+
+	for _, el := range elements {
+		s.Add(el)
+	}
+
+	return s
+}
+
+func (s *Set) Type() ObjectType {
+	return SET_OBJ
+}
+
+func (s *Set) Inspect() string {
+	return inspectContainer(s, visiting{})
+}
+
+func (s *Set) inspect(seen visiting) string {
+
+	elements := []string{}
+
+	for _, el := range s.Elements() {
+		elements = append(elements, reprIn(el, seen))
+	}
+
+	return "#{" + strings.Join(elements, ", ") + "}"
+}
+
+func (s *Set) placeholder() string {
+	return "#{...}"
+}
+
+/**
+ * 名前: Set.Add
+ * 処理: 要素を追加する。既に含まれている場合は何もしない
+ * 引数: 要素
+ * 戻り値: なし
+ */
+func (s *Set) Add(el Hashable) {
+
+	if !s.Contains(el) {
+		s.elements.Set(el, el)
+	}
+}
+
+/**
+ * 名前: Set.Contains
+ * 処理: 要素が含まれているかどうかを判定する
+ * 引数: 要素
+ * 戻り値: bool
+ */
+func (s *Set) Contains(el Hashable) bool {
+
+	_, ok := s.elements.Get(el)
+
+	return ok
+}
+
+/**
+ * 名前: Set.Len
+ * 処理: 要素の数を返す
+ * 引数: なし
+ * 戻り値: int
+ */
+func (s *Set) Len() int {
+	return s.elements.Len()
+}
+
+/**
+ * 名前: Set.Elements
+ * 処理: すべての要素を追加した順に返す
+ * 引数: なし
+ * 戻り値: []Hashable
+ */
+func (s *Set) Elements() []Hashable {
+
+	elements := make([]Hashable, 0, s.Len())
+
+	for _, pair := range s.elements.Pairs() {
+		elements = append(elements, pair.Key.(Hashable))
+	}
+
+	return elements
+}
+
+/**
+ * 名前: Set.Union
+ * 処理: 2つのセットのいずれかに含まれる要素からなるセットを返す
+ * 引数: セット
+ * 戻り値: *Set
+ */
+func (s *Set) Union(other *Set) *Set {
+	return NewSet(append(s.Elements(), other.Elements()...)...)
+}
+
+/**
+ * 名前: Set.Intersection
+ * 処理: 2つのセットの両方に含まれる要素からなるセットを返す
+ * 引数: セット
+ * 戻り値: *Set
+ */
+func (s *Set) Intersection(other *Set) *Set {
+	return s.filter(func(el Hashable) bool { return other.Contains(el) })
+}
+
+/**
+ * 名前: Set.Difference
+ * 処理: other に含まれない要素からなるセットを返す
+ * 引数: セット
+ * 戻り値: *Set
+ */
+func (s *Set) Difference(other *Set) *Set {
+	return s.filter(func(el Hashable) bool { return !other.Contains(el) })
+}
+
+// 条件を満たす要素からなるセットを返す
+func (s *Set) filter(keep func(el Hashable) bool) *Set {
+
+	result := NewSet()
+
+	for _, el := range s.Elements() {
+		if keep(el) {
+			result.Add(el)
+		}
+	}
+
+	return result
+}
+
+// 要素の数が同じで、一方の要素がすべて他方に含まれていればtrueを返す ( 順序は問わない )
+func (s *Set) Equals(other Object) bool {
+
+	o, ok := other.(*Set)
+
+	if !ok || s.Len() != o.Len() {
+		return false
+	}
+
+	for _, el := range s.Elements() {
+		if !o.Contains(el) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,86 @@
+/**
+ * パッケージ名: object
+ * ファイル名: tuple.go
+ * 概要: タプルオブジェクトを定義する
+ */
+package object
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// タプルオブジェクトを表す構造体
+// .. 生成した後は要素を変更しない。要素がすべてハッシュキーとして使えれば、タプルもハッシュキーとして使える
+type Tuple struct {
+	Elements []Object
+}
+
+func (t *Tuple) Type() ObjectType {
+	return TUPLE_OBJ
+}
+
+func (t *Tuple) Inspect() string {
+	return inspectContainer(t, visiting{})
+}
+
+func (t *Tuple) inspect(seen visiting) string {
+
+	elements := []string{}
+
+	for _, el := range t.Elements {
+		elements = append(elements, reprIn(el, seen))
+	}
+
+	// 要素が1つの場合は、括弧でくくった値と区別するため末尾にカンマを付ける
+	if len(elements) == 1 {
+		return "(" + elements[0] + ",)"
+	}
+
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+func (t *Tuple) placeholder() string {
+	return "(...)"
+}
+
+// 要素の数が同じで、各要素が順に等しければtrueを返す
+func (t *Tuple) Equals(other Object) bool {
+	return equalIn(t, other, comparing{})
+}
+
+func (t *Tuple) equals(other Object, seen comparing) bool {
+
+	o, ok := other.(*Tuple)
+
+	if !ok || len(t.Elements) != len(o.Elements) {
+		return false
+	}
+
+	for i, el := range t.Elements {
+		if !equalIn(el, o.Elements[i], seen) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// 要素のハッシュキーを順に連ねてハッシュキーを求める
+// .. 要素がハッシュキーとして使えるかどうかは AsHashable で判定する
+func (t *Tuple) HashKey() HashKey {
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "(%d", len(t.Elements))
+
+	for _, el := range t.Elements {
+
+		if hashable, ok := el.(Hashable); ok {
+			key := hashable.HashKey()
+			fmt.Fprintf(h, "|%s:%d", key.Type, key.Value)
+		}
+	}
+
+	return HashKey{Type: t.Type(), Value: h.Sum64()}
+}
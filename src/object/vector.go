@@ -0,0 +1,252 @@
+/**
+ * パッケージ名: object
+ * ファイル名: vector.go
+ * 概要: 永続ベクタを定義する
+ *  配列とハッシュのペアの並びを保持する。
+ *  要素を 32 個ずつの葉に分けた木で持ち、更新では根から葉までの経路だけを複製する。
+ *  元のベクタは変わらないので、更新前と更新後で木の大部分を共有できる。
+ */
+package object
+
+const (
+	vectorBits  = 5
+	vectorWidth = 1 << vectorBits
+	vectorMask  = vectorWidth - 1
+)
+
+// 永続ベクタの木の節
+// .. 葉は values に要素を、枝は children に子の節を持つ
+type vectorNode[T any] struct {
+	children []*vectorNode[T]
+	values   []T
+}
+
+// 永続ベクタを表す構造体
+// .. 末尾の要素は木に入れずに tail に持ち、tail が一杯になるまでは木を辿らずに追加する
+// .. 一度作ったベクタは書き換えず、更新するときは新しいベクタを返す
+type vector[T any] struct {
+	count int            // 要素の数
+	shift uint           // 根の高さ ( ビット数 )
+	root  *vectorNode[T] // 木の根
+	tail  []T            // 木に入っていない末尾の要素
+}
+
+/**
+ * 名前: newVector
+ * 処理: 要素を並べた永続ベクタを生成する
+ *  生成中のベクタはまだ共有されていないので、複製せずに節を組み立てる
+ * 引数: 要素
+ * 戻り値: *vector
+ */
+func newVector[T any](elements []T) *vector[T] {
+
+	v := &vector[T]{shift: vectorBits, root: &vectorNode[T]{}}
+
+	for start := 0; start < len(elements); start += vectorWidth {
+
+		end := start + vectorWidth
+
+		if end > len(elements) {
+			end = len(elements)
+		}
+
+		// 一杯になった tail を木に移してから、次の要素を tail に入れる
+		if v.count > 0 {
+			v.root, v.shift = v.pushTail()
+		}
+
+		v.tail = append([]T(nil), elements[start:end]...)
+		v.count = end
+	}
+
+	return v
+}
+
+// 木に入っている要素の数 ( tail の先頭の位置 ) を返す
+func (v *vector[T]) tailOffset() int {
+
+	if v.count < vectorWidth {
+		return 0
+	}
+
+	return ((v.count - 1) >> vectorBits) << vectorBits
+}
+
+/**
+ * 名前: vector.Len
+ * 処理: 要素の数を返す
+ * 引数: なし
+ * 戻り値: int
+ */
+func (v *vector[T]) Len() int {
+	return v.count
+}
+
+// i 番目の要素を持つ葉を返す
+func (v *vector[T]) leafFor(i int) []T {
+
+	if i >= v.tailOffset() {
+		return v.tail
+	}
+
+	node := v.root
+
+	for level := v.shift; level > 0; level -= vectorBits {
+		node = node.children[(i>>level)&vectorMask]
+	}
+
+	return node.values
+}
+
+/**
+ * 名前: vector.Get
+ * 処理: i 番目の要素を返す。i は 0 以上 Len() 未満でなければならない
+ * 引数: 位置
+ * 戻り値: 要素
+ */
+func (v *vector[T]) Get(i int) T {
+	return v.leafFor(i)[i&vectorMask]
+}
+
+/**
+ * 名前: vector.Slice
+ * 処理: from 番目から末尾までの要素を新しいスライスに並べて返す
+ * 引数: 開始位置
+ * 戻り値: []T
+ */
+func (v *vector[T]) Slice(from int) []T {
+
+	out := make([]T, 0, v.count-from)
+
+	// 葉ごとにまとめて写す
+	for i := from; i < v.count; {
+		leaf := v.leafFor(i)
+		chunk := leaf[i&vectorMask:]
+		out = append(out, chunk...)
+		i += len(chunk)
+	}
+
+	return out
+}
+
+/**
+ * 名前: vector.Push
+ * 処理: 末尾に要素を追加したベクタを返す
+ * 引数: 要素
+ * 戻り値: *vector
+ */
+func (v *vector[T]) Push(value T) *vector[T] {
+
+	// tail に空きがあれば、tail だけを複製する
+	if v.count-v.tailOffset() < vectorWidth {
+
+		tail := make([]T, len(v.tail), len(v.tail)+1)
+		copy(tail, v.tail)
+
+		return &vector[T]{
+			count: v.count + 1,
+			shift: v.shift,
+			root:  v.root,
+			tail:  append(tail, value),
+		}
+	}
+
+	root, shift := v.pushTail()
+
+	return &vector[T]{count: v.count + 1, shift: shift, root: root, tail: []T{value}}
+}
+
+// 一杯になった tail を葉として木に加えた根と、その高さを返す
+func (v *vector[T]) pushTail() (*vectorNode[T], uint) {
+
+	leaf := &vectorNode[T]{values: v.tail}
+
+	// 根が一杯の場合は、木を1段高くする
+	if (v.count >> vectorBits) > (1 << v.shift) {
+
+		root := &vectorNode[T]{children: []*vectorNode[T]{v.root, newVectorPath(v.shift, leaf)}}
+
+		return root, v.shift + vectorBits
+	}
+
+	return v.pushLeaf(v.shift, v.root, leaf), v.shift
+}
+
+// parent を複製し、その下に葉を加える
+func (v *vector[T]) pushLeaf(level uint, parent *vectorNode[T], leaf *vectorNode[T]) *vectorNode[T] {
+
+	index := ((v.count - 1) >> level) & vectorMask
+
+	node := &vectorNode[T]{children: make([]*vectorNode[T], len(parent.children), len(parent.children)+1)}
+	copy(node.children, parent.children)
+
+	var child *vectorNode[T]
+
+	switch {
+	case level == vectorBits:
+		child = leaf
+	case index < len(parent.children):
+		child = v.pushLeaf(level-vectorBits, parent.children[index], leaf)
+	default:
+		child = newVectorPath(level-vectorBits, leaf)
+	}
+
+	if index < len(node.children) {
+		node.children[index] = child
+	} else {
+		node.children = append(node.children, child)
+	}
+
+	return node
+}
+
+// 葉までの枝を level の高さだけ作る
+func newVectorPath[T any](level uint, leaf *vectorNode[T]) *vectorNode[T] {
+
+	if level == 0 {
+		return leaf
+	}
+
+	return &vectorNode[T]{children: []*vectorNode[T]{newVectorPath(level-vectorBits, leaf)}}
+}
+
+/**
+ * 名前: vector.Set
+ * 処理: i 番目の要素を置き換えたベクタを返す。i は 0 以上 Len() 未満でなければならない
+ * 引数: 位置, 要素
+ * 戻り値: *vector
+ */
+func (v *vector[T]) Set(i int, value T) *vector[T] {
+
+	if i >= v.tailOffset() {
+
+		tail := make([]T, len(v.tail))
+		copy(tail, v.tail)
+		tail[i&vectorMask] = value
+
+		return &vector[T]{count: v.count, shift: v.shift, root: v.root, tail: tail}
+	}
+
+	return &vector[T]{count: v.count, shift: v.shift, root: setVectorNode(v.shift, v.root, i, value), tail: v.tail}
+}
+
+// node を複製し、その下の i 番目の要素を置き換える
+func setVectorNode[T any](level uint, node *vectorNode[T], i int, value T) *vectorNode[T] {
+
+	if level == 0 {
+
+		values := make([]T, len(node.values))
+		copy(values, node.values)
+		values[i&vectorMask] = value
+
+		return &vectorNode[T]{values: values}
+	}
+
+	children := make([]*vectorNode[T], len(node.children))
+	copy(children, node.children)
+
+	index := (i >> level) & vectorMask
+	children[index] = setVectorNode(level-vectorBits, children[index], i, value)
+
+	return &vectorNode[T]{children: children}
+}
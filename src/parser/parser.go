@@ -7,7 +7,9 @@ package parser
 
 import (
 	"fmt"
+	"path"
 	"strconv"
+	"strings"
 
 	"github.com/MasaruFukazawa/monkey-lang/src/ast"
 	"github.com/MasaruFukazawa/monkey-lang/src/lexer"
@@ -18,9 +20,15 @@ const (
 	_ int = iota
 	// LOWEST: 優先順位の最低値
 	LOWEST
+	// ASSIGN: =
+	ASSIGN
+	// PIPELINE: |>
+	PIPELINE
+	// NULLISH: ??
+	NULLISH
 	// EQUALS: ==
 	EQUALS
-	// LESSGREATER: > または <
+	// LESSGREATER: > または < または in
 	LESSGREATER
 	// SUM: +
 	SUM
@@ -36,16 +44,23 @@ const (
 
 // 優先順位のマップ
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,      // ==
-	token.NOT_EQ:   EQUALS,      // !=
-	token.LT:       LESSGREATER, // <
-	token.GT:       LESSGREATER, // >
-	token.PLUS:     SUM,         // +
-	token.MINUS:    SUM,         // -
-	token.SLASH:    PRODUCT,     // /
-	token.ASTERISK: PRODUCT,     // *
-	token.LPAREN:   CALL,        //
-	token.LBRACKET: INDEX,       //
+	token.ASSIGN:            ASSIGN,      // =
+	token.PIPELINE:          PIPELINE,    // |>
+	token.NULLISH:           NULLISH,     // ??
+	token.EQ:                EQUALS,      // ==
+	token.NOT_EQ:            EQUALS,      // !=
+	token.LT:                LESSGREATER, // <
+	token.GT:                LESSGREATER, // >
+	token.IN:                LESSGREATER, // in
+	token.PLUS:              SUM,         // +
+	token.MINUS:             SUM,         // -
+	token.SLASH:             PRODUCT,     // /
+	token.ASTERISK:          PRODUCT,     // *
+	token.LPAREN:            CALL,        //
+	token.LBRACKET:          INDEX,       //
+	token.OPTIONAL_LBRACKET: INDEX,       // ?[
+	token.OPTIONAL_DOT:      INDEX,       // ?.
+	token.DOT:               INDEX,       // .
 }
 
 // 優先順位の定義
@@ -72,6 +87,25 @@ type Parser struct {
 
 	// 中置構文解析関数のマップ
 	infixParseFns map[token.TokenType]infixParseFn
+
+	// trueの間はアロー関数を構文解析しない
+	// .. match式のガード条件 ( x if ok => ... ) の => を腕の区切りとして扱うため
+	noArrow bool
+
+	// 宣言された名前のスコープの一覧 ( 末尾が最も内側 )
+	// .. 値がtrueの名前はconstとして宣言されている
+	scopes []map[string]bool
+
+	// 内包表記かどうかが決まるまで、constへの代入の判定を待つ名前
+	// .. [c = 2 for c in xs] の c は、for節まで読まないと反復する変数かどうかが分からないため
+	held *heldAssignments
+}
+
+// constへの代入の判定を待っている名前
+type heldAssignments struct {
+	depth int               // 要素の構文解析を始めたときのスコープの数
+	names []*ast.Identifier // 要素より外で宣言された ( または見つからない ) 代入先の名前
+	outer *heldAssignments  // 外側の要素で判定を待っている名前
 }
 
 /**
@@ -127,10 +161,22 @@ func (p *Parser) ParseProgram() *ast.Program {
 func (p *Parser) parseStatement() ast.Statement {
 
 	switch p.curToken.Type {
-	case token.LET: // let
+	case token.LET, token.CONST: // let, const
 		return p.parseLetStatement()
 	case token.RETURN: // return
 		return p.parseReturnStatement()
+	case token.THROW: // throw
+		return p.parseThrowStatement()
+	case token.DEFER: // defer
+		return p.parseDeferStatement()
+	case token.STRUCT: // struct
+		return p.parseStructStatement()
+	case token.ENUM: // enum
+		return p.parseEnumStatement()
+	case token.IMPORT: // import
+		return p.parseImportStatement()
+	case token.EXPORT: // export
+		return p.parseExportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -155,6 +201,11 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	// IDENTを持つast.Identifierを生成
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
+	// 型注釈 ( let x: int = 1 ) を構文解析
+	if !p.parseOptionalType(stmt.Name) {
+		return nil
+	}
+
 	// 次のトークンがASSIGNでなければnilを返す
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
@@ -164,6 +215,9 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
+	// 値の式より後で宣言する ( let x = x + 1 の右辺のxは外側の変数を指す )
+	p.declare(stmt.Name.Value, stmt.IsConst())
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -187,7 +241,8 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	// セミコロンは省略できる
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -195,20 +250,23 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 }
 
 /**
- * 名前: Parser.parseExpressionStatement
- * 処続: 構文解析を行う
+ * 名前: Parser.parseThrowStatement
+ * 処理: throw文を構文解析する
  * 引数: なし
- * 戻値: ast.ExpressionStatement
+ * 戻値: ast.Statement
  */
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+func (p *Parser) parseThrowStatement() ast.Statement {
 
-	// expressionを持つast.ExpressionStatementのポインタを生成
-	stmt := &ast.ExpressionStatement{Token: p.curToken}
+	stmt := &ast.ThrowStatement{Token: p.curToken}
 
-	// 式を構文解析する
-	stmt.Expression = p.parseExpression(LOWEST)
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if stmt.Value == nil {
+		return nil
+	}
 
-	// セミコロンに遭遇するまで式を読み飛ばす
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -217,300 +275,1919 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 }
 
 /**
- * 名前: Parse.noPrefixParseFnError
- * 処理: 構文解析を行う
- * 引数: token.TokenType
- * 戻値: なし
+ * 名前: Parser.parseDeferStatement
+ * 処理: defer文を構文解析する
+ *  defer の後には関数呼び出しだけを書ける
+ * 引数: なし
+ * 戻値: ast.Statement
  */
-func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
-}
+func (p *Parser) parseDeferStatement() ast.Statement {
 
-/**
- * 名前: Parser.parseExpression
- * 処続: 構文解析を行う
- * 引数: int
- * 戻値: ast.Expression
- */
-func (p *Parser) parseExpression(precedence int) ast.Expression {
+	stmt := &ast.DeferStatement{Token: p.curToken}
 
-	// 前置構文解析関数を取得
-	prefix := p.prefixParseFns[p.curToken.Type]
+	p.nextToken()
 
-	// 前置構文解析関数がnilであればnilを返す
-	if prefix == nil {
-		p.noPrefixParseFnError(p.curToken.Type)
+	expression := p.parseExpression(LOWEST)
+
+	if expression == nil {
 		return nil
 	}
 
-	// 前置構文解析関数を実行
-	leftExp := prefix()
-
-	// 次のトークンがセミコロンでないかつ、引数の優先順位よりも低い場合は繰り返す
-	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
+	call, ok := expression.(*ast.CallExpression)
 
-		infix := p.infixParseFns[p.peekToken.Type]
+	if !ok {
+		msg := fmt.Sprintf("defer expects a function call, got %s", expression.String())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
 
-		if infix == nil {
-			return leftExp
-		}
+	stmt.Call = call
 
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
-
-		leftExp = infix(leftExp)
 	}
 
-	return leftExp
+	return stmt
 }
 
 /**
- * 名前: Parser.parseIdentifier
- * 処続: 構文解析を行う
+ * 名前: Parser.parseStructStatement
+ * 処理: 構造体の定義を構文解析する
+ *  フィールド名はカンマで区切り、メソッドは fn 名前(引数) { 本体 } で定義する
  * 引数: なし
- * 戻値: ast.Expression
+ * 戻値: ast.Statement
  */
-func (p *Parser) parseIdentifier() ast.Expression {
-	// IDENTを持つast.Identifierポインタを生成
-	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-}
+func (p *Parser) parseStructStatement() ast.Statement {
 
-/**
- * 名前: Parser.curTokenIs
- * 処続: 現在のトークンが引数のトークンと同じかどうかを返す
- * 引数: token.TokenType
- * 戻値: bool
- */
-func (p *Parser) curTokenIs(t token.TokenType) bool {
-	return p.curToken.Type == t
-}
+	stmt := &ast.StructStatement{Token: p.curToken}
 
-/**
- * 名前: Parser.peekTokenIs
- * 処続: 次のトークンが引数のトークンと同じかどうかを返す
- * 引数: token.TokenType
- * 戻値: bool
- */
-func (p *Parser) peekTokenIs(t token.TokenType) bool {
-	return p.peekToken.Type == t
-}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
 
-/**
- * 名前: Parser.expectPeek
- * 処続: 次のトークンが引数のトークンと同じであれば、次のトークンへ進める
- * 引数: token.TokenType
- * 戻値: bool
- */
-func (p *Parser) expectPeek(t token.TokenType) bool {
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	// 次のトークンが引数のトークンと同じであれば、次のトークンへ進める
-	if p.peekTokenIs(t) {
-		p.nextToken()
-		return true
-	} else {
-		// 次のトークンが引数のトークンと同じでなければ、エラーを追加
-		p.peekError(t)
-		return false
+	p.declare(stmt.Name.Value, false)
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
 	}
-}
 
-/**
- * 名前: Parser.errors
- * 処続: 構文解析中に発生したエラーを返す
- * 引数: なし
- * 戻値: []string
- */
-func (p *Parser) Errors() []string {
-	return p.errors
-}
+	// フィールド名とメソッド名は重複できない
+	members := map[string]bool{}
 
-/**
- * 名前: Parser.peekError
- * 処続: 次のトークンが引数のトークンと同じでなければ、エラーを追加する
- * 引数: token.TokenType
- * 戻値: なし
- */
-func (p *Parser) peekError(t token.TokenType) {
+	p.nextToken()
 
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
+	for !p.curTokenIs(token.RBRACE) {
 
-	// エラーを追加
-	p.errors = append(p.errors, msg)
-}
+		var name *ast.Identifier
 
-/**
- * 名前: Parser.registerPrefix
- * 概要: 引数のトークンタイプに対応する前置構文解析関数を登録する
- * 引数: token.TokenType, func() ast.Expression
- * 戻値: なし
- */
-func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
-	p.prefixParseFns[tokenType] = fn
-}
+		switch p.curToken.Type {
+		case token.IDENT:
+			name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			stmt.Fields = append(stmt.Fields, name)
+		case token.FUNCTION:
+			method := p.parseStructMethod()
 
-/**
- * 名前: Parser.registerInfix
- * 概要: 引数のトークンタイプに対応する中置構文解析関数を登録する
- * 引数: token.TokenType, func(ast.Expression) ast.Expression
- * 戻値: なし
- */
-func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
-	p.infixParseFns[tokenType] = fn
-}
+			if method == nil {
+				return nil
+			}
 
-/**
- * 名前: Parser.parseIntegerLiteral
- * 概要: 整数リテラルを構文解析する
- * 引数: なし
- * 戻値: ast.Expression
- */
-func (p *Parser) parseIntegerLiteral() ast.Expression {
+			name = method.Name
+			stmt.Methods = append(stmt.Methods, method)
+		default:
+			msg := fmt.Sprintf("expected field or method in struct %s, got %s instead", stmt.Name.Value, p.curToken.Type)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
 
-	lit := &ast.IntegerLiteral{Token: p.curToken}
+		if members[name.Value] {
+			msg := fmt.Sprintf("duplicate member %s in struct %s", name.Value, stmt.Name.Value)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
 
-	// 文字列をint64に変換
-	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+		members[name.Value] = true
 
-	// エラーが発生した場合はエラーを追加
-	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		// フィールドやメソッドの後のカンマ・セミコロンは読み飛ばす
+		if p.peekTokenIs(token.COMMA) || p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
 
-		// nilを返す
-		return nil
+		p.nextToken()
 	}
 
-	// 整数リテラルの値を設定
-	lit.Value = value
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
 
-	return lit
+	return stmt
 }
 
 /**
- * 名前: Parser.parsePrefixExpression
- * 概要: 前置演算子を構文解析する
+ * 名前: Parser.parseStructMethod
+ * 処理: 構造体のメソッドの定義を構文解析する
+ *  メソッドの本体では、引数に加えて self を宣言する
  * 引数: なし
- * 戻値: ast.Expression
+ * 戻値: *ast.StructMethod
  */
-func (p *Parser) parsePrefixExpression() ast.Expression {
+func (p *Parser) parseStructMethod() *ast.StructMethod {
 
-	// 前置演算子を持つast.PrefixExpressionポインタを生成
-	expression := &ast.PrefixExpression{
-		Token:    p.curToken,
-		Operator: p.curToken.Literal,
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
 	}
 
-	// 次のトークンへ進める
-	p.nextToken()
+	method := &ast.StructMethod{
+		Name:     &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		Function: lit,
+	}
 
-	// 式を構文解析
-	expression.Right = p.parseExpression(PREFIX)
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
 
-	return expression
-}
+	lit.Parameters = p.parseFunctionParameters()
 
-/**
- * 名前: Parser.peekPrecedence
- * 概要: 次のトークンの優先順位を返す
- * 引数: なし
- * 戻値: int
- */
-func (p *Parser) peekPrecedence() int {
+	if !p.parseOptionalReturnType(lit) {
+		return nil
+	}
 
-	if p, ok := precedences[p.peekToken.Type]; ok {
-		return p
+	if !p.expectPeek(token.LBRACE) {
+		return nil
 	}
 
-	// トークンが見つからなかった場合はLOWESTを返す
-	return LOWEST
-}
+	p.enterScope()
+	defer p.leaveScope()
 
-/**
- * 名前: Parser.curPrecedence
- * 概要: 現在のトークンの優先順位を返す
- * 引数: なし
- * 戻値: int
- */
-func (p *Parser) curPrecedence() int {
+	p.declare("self", false)
 
-	if p, ok := precedences[p.curToken.Type]; ok {
-		return p
+	for _, param := range lit.Parameters {
+		p.declare(param.Value, false)
 	}
 
-	// トークンが見つからなかった場合はLOWESTを返す
-	return LOWEST
+	lit.Body = p.parseBlockStatement()
+
+	return method
 }
 
 /**
- * 名前: Parser.parseInfixExpression
- * 概要: 中置演算子を構文解析する
- * 引数: ast.Expression
- * 戻値: ast.Expression
+ * 名前: Parser.parseEnumStatement
+ * 処理: 列挙型の定義を構文解析する
+ *  バリアントはカンマで区切り、ペイロードを持つバリアントはフィールド名を ( ) で囲む
+ * 引数: なし
+ * 戻値: ast.Statement
  */
-func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+func (p *Parser) parseEnumStatement() ast.Statement {
 
-	expression := &ast.InfixExpression{
-		Token:    p.curToken,
-		Operator: p.curToken.Literal,
-		Left:     left,
+	stmt := &ast.EnumStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
 	}
 
-	// 現在のトークンの優先順位を取得
-	precedence := p.curPrecedence()
-	p.nextToken()
-	expression.Right = p.parseExpression(precedence)
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	return expression
-}
+	p.declare(stmt.Name.Value, false)
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	seen := map[string]bool{}
+
+	for !p.peekTokenIs(token.RBRACE) {
+
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		variant := &ast.EnumVariant{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+
+		if seen[variant.Name.Value] {
+			msg := fmt.Sprintf("duplicate variant %s in enum %s", variant.Name.Value, stmt.Name.Value)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+
+		seen[variant.Name.Value] = true
+
+		if p.peekTokenIs(token.LPAREN) {
+
+			p.nextToken()
+
+			variant.Fields = p.parseFunctionParameters()
+
+			for _, field := range variant.Fields {
+				if field.Type != nil {
+					msg := fmt.Sprintf("type annotations are not allowed on enum fields: %s.%s", stmt.Name.Value, variant.Name.Value)
+					p.errors = append(p.errors, msg)
+					return nil
+				}
+			}
+
+			if len(variant.Fields) == 0 {
+				msg := fmt.Sprintf("variant %s.%s has no fields, omit the parentheses", stmt.Name.Value, variant.Name.Value)
+				p.errors = append(p.errors, msg)
+				return nil
+			}
+		}
+
+		stmt.Variants = append(stmt.Variants, variant)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+/**
+ * 名前: Parser.parseImportStatement
+ * 処理: import文を構文解析する
+ *  as を省略した場合は、ファイル名から拡張子を除いた名前に束縛する
+ * 引数: なし
+ * 戻値: ast.Statement
+ */
+func (p *Parser) parseImportStatement() ast.Statement {
+
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	// 次のトークンがSTRINGでなければnilを返す
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+
+	stmt.Path = p.curToken.Literal
+
+	if p.peekTokenIs(token.AS) {
+
+		p.nextToken()
+
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	} else {
+
+		base := path.Base(stmt.Path)
+		name := strings.TrimSuffix(base, path.Ext(base))
+
+		if !isIdentifier(name) {
+			msg := fmt.Sprintf("cannot derive module name from %q, use `as`", stmt.Path)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+
+		tok := token.Token{Type: token.IDENT, Literal: name}
+		stmt.Name = &ast.Identifier{Token: tok, Value: name}
+	}
+
+	p.declare(stmt.Name.Value, false)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+/**
+ * 名前: Parser.parseExportStatement
+ * 処理: export文を構文解析する。export はプログラムの最も外側のスコープにしか書けない
+ * 引数: なし
+ * 戻値: ast.Statement
+ */
+func (p *Parser) parseExportStatement() ast.Statement {
+
+	stmt := &ast.ExportStatement{Token: p.curToken}
+
+	if len(p.scopes) != 1 {
+		p.errors = append(p.errors, "export is only allowed at the top level")
+		return nil
+	}
+
+	p.nextToken()
+
+	switch p.curToken.Type {
+	case token.LET, token.CONST:
+		declaration := p.parseLetStatement()
+
+		if declaration == nil {
+			return nil
+		}
+
+		stmt.Statement = declaration
+	case token.STRUCT:
+		declaration := p.parseStructStatement()
+
+		if declaration == nil {
+			return nil
+		}
+
+		stmt.Statement = declaration
+	case token.ENUM:
+		declaration := p.parseEnumStatement()
+
+		if declaration == nil {
+			return nil
+		}
+
+		stmt.Statement = declaration
+	default:
+		msg := fmt.Sprintf("expected declaration after export, got %s instead", p.curToken.Type)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	return stmt
+}
+
+/**
+ * 名前: isIdentifier
+ * 処理: 文字列が識別子(変数名・関数名)として使えるかどうかを判定する
+ * 引数: 文字列
+ * 戻値: bool
+ */
+func isIdentifier(s string) bool {
+
+	if s == "" || token.LookupIdent(s) != token.IDENT {
+		return false
+	}
+
+	for _, ch := range s {
+		if !('a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_') {
+			return false
+		}
+	}
+
+	return true
+}
+
+/**
+ * 名前: Parser.parseExpressionStatement
+ * 処続: 構文解析を行う
+ * 引数: なし
+ * 戻値: ast.ExpressionStatement
+ */
+func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+
+	// expressionを持つast.ExpressionStatementのポインタを生成
+	stmt := &ast.ExpressionStatement{Token: p.curToken}
+
+	// 式を構文解析する
+	stmt.Expression = p.parseExpression(LOWEST)
+
+	// セミコロンに遭遇するまで式を読み飛ばす
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+/**
+ * 名前: Parse.noPrefixParseFnError
+ * 処理: 構文解析を行う
+ * 引数: token.TokenType
+ * 戻値: なし
+ */
+func (p *Parser) noPrefixParseFnError(t token.TokenType) {
+	msg := fmt.Sprintf("no prefix parse function for %s found", t)
+	p.errors = append(p.errors, msg)
+}
+
+/**
+ * 名前: Parser.parseExpression
+ * 処続: 構文解析を行う
+ * 引数: int
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseExpression(precedence int) ast.Expression {
+
+	// 前置構文解析関数を取得
+	prefix := p.prefixParseFns[p.curToken.Type]
+
+	// 前置構文解析関数がnilであればnilを返す
+	if prefix == nil {
+		p.noPrefixParseFnError(p.curToken.Type)
+		return nil
+	}
+
+	// 前置構文解析関数を実行
+	leftExp := prefix()
+
+	// 次のトークンがセミコロンでないかつ、引数の優先順位よりも低い場合は繰り返す
+	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
+
+		infix := p.infixParseFns[p.peekToken.Type]
+
+		if infix == nil {
+			return leftExp
+		}
+
+		p.nextToken()
+
+		leftExp = infix(leftExp)
+	}
+
+	return leftExp
+}
+
+/**
+ * 名前: Parser.parseIdentifier
+ * 処続: 構文解析を行う
+ * 引数: なし
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseIdentifier() ast.Expression {
+
+	// IDENTを持つast.Identifierポインタを生成
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	// x => ... であれば、アロー関数とする
+	if p.peekTokenIs(token.ARROW) && !p.noArrow {
+		return p.parseArrowFunction([]*ast.Identifier{ident})
+	}
+
+	return ident
+}
+
+/**
+ * 名前: Parser.curTokenIs
+ * 処続: 現在のトークンが引数のトークンと同じかどうかを返す
+ * 引数: token.TokenType
+ * 戻値: bool
+ */
+func (p *Parser) curTokenIs(t token.TokenType) bool {
+	return p.curToken.Type == t
+}
+
+/**
+ * 名前: Parser.peekTokenIs
+ * 処続: 次のトークンが引数のトークンと同じかどうかを返す
+ * 引数: token.TokenType
+ * 戻値: bool
+ */
+func (p *Parser) peekTokenIs(t token.TokenType) bool {
+	return p.peekToken.Type == t
+}
+
+/**
+ * 名前: Parser.expectPeek
+ * 処続: 次のトークンが引数のトークンと同じであれば、次のトークンへ進める
+ * 引数: token.TokenType
+ * 戻値: bool
+ */
+func (p *Parser) expectPeek(t token.TokenType) bool {
+
+	// 次のトークンが引数のトークンと同じであれば、次のトークンへ進める
+	if p.peekTokenIs(t) {
+		p.nextToken()
+		return true
+	} else {
+		// 次のトークンが引数のトークンと同じでなければ、エラーを追加
+		p.peekError(t)
+		return false
+	}
+}
+
+/**
+ * 名前: Parser.errors
+ * 処続: 構文解析中に発生したエラーを返す
+ * 引数: なし
+ * 戻値: []string
+ */
+func (p *Parser) Errors() []string {
+	return p.errors
+}
+
+/**
+ * 名前: Parser.peekError
+ * 処続: 次のトークンが引数のトークンと同じでなければ、エラーを追加する
+ * 引数: token.TokenType
+ * 戻値: なし
+ */
+func (p *Parser) peekError(t token.TokenType) {
+
+	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
+
+	// エラーを追加
+	p.errors = append(p.errors, msg)
+}
+
+/**
+ * 名前: Parser.registerPrefix
+ * 概要: 引数のトークンタイプに対応する前置構文解析関数を登録する
+ * 引数: token.TokenType, func() ast.Expression
+ * 戻値: なし
+ */
+func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
+	p.prefixParseFns[tokenType] = fn
+}
+
+/**
+ * 名前: Parser.registerInfix
+ * 概要: 引数のトークンタイプに対応する中置構文解析関数を登録する
+ * 引数: token.TokenType, func(ast.Expression) ast.Expression
+ * 戻値: なし
+ */
+func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
+	p.infixParseFns[tokenType] = fn
+}
+
+/**
+ * 名前: Parser.parseIntegerLiteral
+ * 概要: 整数リテラルを構文解析する
+ * 引数: なし
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseIntegerLiteral() ast.Expression {
+
+	lit := &ast.IntegerLiteral{Token: p.curToken}
+
+	// 文字列をint64に変換
+	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+
+	// エラーが発生した場合はエラーを追加
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+
+		// nilを返す
+		return nil
+	}
+
+	// 整数リテラルの値を設定
+	lit.Value = value
+
+	return lit
+}
+
+/**
+ * 名前: Parser.parsePrefixExpression
+ * 概要: 前置演算子を構文解析する
+ * 引数: なし
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parsePrefixExpression() ast.Expression {
+
+	// 前置演算子を持つast.PrefixExpressionポインタを生成
+	expression := &ast.PrefixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+	}
+
+	// 次のトークンへ進める
+	p.nextToken()
+
+	// 式を構文解析
+	expression.Right = p.parseExpression(PREFIX)
+
+	return expression
+}
+
+/**
+ * 名前: Parser.peekPrecedence
+ * 概要: 次のトークンの優先順位を返す
+ * 引数: なし
+ * 戻値: int
+ */
+func (p *Parser) peekPrecedence() int {
+
+	if p, ok := precedences[p.peekToken.Type]; ok {
+		return p
+	}
+
+	// トークンが見つからなかった場合はLOWESTを返す
+	return LOWEST
+}
+
+/**
+ * 名前: Parser.curPrecedence
+ * 概要: 現在のトークンの優先順位を返す
+ * 引数: なし
+ * 戻値: int
+ */
+func (p *Parser) curPrecedence() int {
+
+	if p, ok := precedences[p.curToken.Type]; ok {
+		return p
+	}
+
+	// トークンが見つからなかった場合はLOWESTを返す
+	return LOWEST
+}
+
+/**
+ * 名前: Parser.parseInfixExpression
+ * 概要: 中置演算子を構文解析する
+ * 引数: ast.Expression
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+
+	expression := &ast.InfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+
+	// 現在のトークンの優先順位を取得
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+
+	return expression
+}
+
+/**
+ * 名前: Parser.parsePipeExpression
+ * 概要: パイプライン式を構文解析する。左結合とする
+ * 引数: ast.Expression
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+
+	expression := &ast.PipeExpression{Token: p.curToken, Left: left}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+
+	return expression
+}
+
+/**
+ * 名前: Parser.parseAssignExpression
+ * 概要: 代入式を構文解析する。右結合とする
+ * 引数: ast.Expression
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+
+	expression := &ast.AssignExpression{Token: p.curToken}
+
+	// 代入先は識別子に限る
+	name, ok := left.(*ast.Identifier)
+
+	if !ok {
+		msg := fmt.Sprintf("invalid assignment target: %s", left.String())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	expression.Name = name
+
+	// constとして宣言された名前への代入は構文解析の時点でエラーとする
+	p.checkConstAssignment(name)
+
+	p.nextToken()
+
+	// 右結合とするため、1つ低い優先順位で右辺を構文解析する
+	expression.Value = p.parseExpression(ASSIGN - 1)
+
+	return expression
+}
+
+/**
+ * 名前: Parser.enterScope
+ * 概要: 新しいスコープに入る
+ * 引数: なし
+ * 戻値: なし
+ */
+func (p *Parser) enterScope() {
+	p.scopes = append(p.scopes, map[string]bool{})
+}
+
+/**
+ * 名前: Parser.leaveScope
+ * 概要: 現在のスコープから出る
+ * 引数: なし
+ * 戻値: なし
+ */
+func (p *Parser) leaveScope() {
+	p.scopes = p.scopes[:len(p.scopes)-1]
+}
+
+/**
+ * 名前: Parser.declare
+ * 概要: 現在のスコープで名前を宣言する
+ *  同じスコープでconstとして宣言された名前は再宣言できない
+ * 引数: 名前, constかどうか
+ * 戻値: なし
+ */
+func (p *Parser) declare(name string, isConst bool) {
+
+	scope := p.scopes[len(p.scopes)-1]
+
+	if scope[name] {
+		msg := fmt.Sprintf("cannot redeclare constant %s", name)
+		p.errors = append(p.errors, msg)
+		return
+	}
+
+	scope[name] = isConst
+}
+
+/**
+ * 名前: Parser.resolveConst
+ * 概要: 名前を内側のスコープから探し、constとして宣言されていればtrueを返す
+ *  構文解析の時点で見つからない名前 ( REPLの前の行で宣言された名前など ) は実行時に判定する
+ * 引数: 名前
+ * 戻値: bool, 名前を宣言したスコープの位置 ( 見つからない場合は -1 )
+ */
+func (p *Parser) resolveConst(name string) (bool, int) {
+
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if isConst, ok := p.scopes[i][name]; ok {
+			return isConst, i
+		}
+	}
+
+	return false, -1
+}
+
+/**
+ * 名前: Parser.checkConstAssignment
+ * 概要: 代入先がconstとして宣言された名前であればエラーとする
+ *  内包表記かもしれない要素の中で、要素より外の名前へ代入する場合は、判定を待つ
+ * 引数: 代入先の名前
+ * 戻値: なし
+ */
+func (p *Parser) checkConstAssignment(name *ast.Identifier) {
+
+	isConst, depth := p.resolveConst(name.Value)
+
+	if p.held != nil && depth < p.held.depth {
+		p.held.names = append(p.held.names, name)
+		return
+	}
+
+	if isConst {
+		msg := fmt.Sprintf("cannot assign to constant %s", name.Value)
+		p.errors = append(p.errors, msg)
+	}
+}
+
+/**
+ * 名前: Parser.holdConstAssignments
+ * 概要: 内包表記かもしれない要素の構文解析の間、constへの代入の判定を待つ
+ * 引数: なし
+ * 戻値: 判定を待つ名前の一覧
+ */
+func (p *Parser) holdConstAssignments() *heldAssignments {
+
+	p.held = &heldAssignments{depth: len(p.scopes), outer: p.held}
+
+	return p.held
+}
+
+/**
+ * 名前: Parser.releaseConstAssignments
+ * 概要: 判定を待っていた代入先を、現在のスコープで判定する
+ *  内包表記であれば、反復する変数を宣言したスコープで呼び出す
+ *  外側の要素でも判定を待っている場合は、そちらに引き継ぐ
+ * 引数: 判定を待つ名前の一覧
+ * 戻値: なし
+ */
+func (p *Parser) releaseConstAssignments(held *heldAssignments) {
+
+	p.held = held.outer
+
+	for _, name := range held.names {
+		p.checkConstAssignment(name)
+	}
+}
+
+/**
+ * 名前: Parser.parseBoolean
+ * 概要: 真偽値を構文解析する
+ * 引数: なし
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseBoolean() ast.Expression {
+	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
+}
+
+/**
+ * 名前: Parser.parseGroupedExpression
+ * 概要:
+ * 引数: なし
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseGroupedExpression() ast.Expression {
+
+	// (a, b) => ... であれば、アロー関数とする
+	if !p.noArrow && p.isArrowParameterList() {
+
+		params := p.parseFunctionParameters()
+
+		if params == nil {
+			return nil
+		}
+
+		return p.parseArrowFunction(params)
+	}
+
+	lparen := p.curToken
+
+	// () であれば、空のタプルとする
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return &ast.TupleLiteral{Token: lparen, Elements: []ast.Expression{}}
+	}
+
+	p.nextToken()
+
+	exp := p.parseExpression(LOWEST)
+
+	// (a, b) や (a,) であれば、タプルとする
+	if p.peekTokenIs(token.COMMA) {
+		return p.parseTupleLiteral(lparen, exp)
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return exp
+}
+
+/**
+ * 名前: Parser.parseTupleLiteral
+ * 概要: 1つ目の要素に続くタプルリテラルの残りを構文解析する。末尾のカンマは読み飛ばす
+ * 引数: '(' トークン, 1つ目の要素
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseTupleLiteral(lparen token.Token, first ast.Expression) ast.Expression {
+
+	tuple := &ast.TupleLiteral{Token: lparen, Elements: []ast.Expression{first}}
+
+	for p.peekTokenIs(token.COMMA) {
+
+		p.nextToken()
+
+		if p.peekTokenIs(token.RPAREN) {
+			break
+		}
+
+		p.nextToken()
+
+		tuple.Elements = append(tuple.Elements, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return tuple
+}
+
+/**
+ * 名前: Parser.parseIfExpression
+ * 概要: if文を構文解析する
+ * 引数: なし
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseIfExpression() ast.Expression {
+
+	expression := &ast.IfExpression{Token: p.curToken}
+
+	// 次のトークンへ進める
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	// 条件式を構文解析
+	p.nextToken()
+
+	expression.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	// Consequenceを構文解析
+	expression.Consequence = p.parseBlockStatement()
+
+	// 次のトークンがELSEであれば、ELSEブロックを構文解析
+	if p.peekTokenIs(token.ELSE) {
+
+		p.nextToken()
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		// Alternativeを構文解析
+		expression.Alternative = p.parseBlockStatement()
+	}
+
+	return expression
+}
+
+/**
+ * 名前: Parser.parseTryExpression
+ * 概要: try式を構文解析する
+ *  try { ... } catch (e) { ... } finally { ... }
+ * 引数: なし
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseTryExpression() ast.Expression {
+
+	expression := &ast.TryExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Block = p.parseBlockStatement()
+
+	// catch節を構文解析
+	// .. (e) を省略した場合は、捕捉した例外を変数に束縛しない
+	if p.peekTokenIs(token.CATCH) {
+
+		p.nextToken()
+
+		if p.peekTokenIs(token.LPAREN) {
+
+			p.nextToken()
+
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+
+			expression.Parameter = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+			if !p.expectPeek(token.RPAREN) {
+				return nil
+			}
+		}
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		// 例外を束縛する変数は catch のブロックを囲むスコープで宣言する
+		p.enterScope()
+
+		if expression.Parameter != nil {
+			p.declare(expression.Parameter.Value, false)
+		}
+
+		expression.Catch = p.parseBlockStatement()
+
+		p.leaveScope()
+	}
+
+	// finally節を構文解析
+	if p.peekTokenIs(token.FINALLY) {
+
+		p.nextToken()
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		expression.Finally = p.parseBlockStatement()
+	}
+
+	if expression.Catch == nil && expression.Finally == nil {
+		p.errors = append(p.errors, "expected catch or finally after try block")
+		return nil
+	}
+
+	return expression
+}
+
+/**
+ * 名前: Parser.parseBlockStatement
+ * 概要: ブロック文を構文解析する
+ * 引数: なし
+ * 戻値: *ast.BlockStatement
+ */
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+
+	block := &ast.BlockStatement{Token: p.curToken}
+
+	// Statementsにast.Statementを追加していく
+	block.Statements = []ast.Statement{}
+
+	// ブロックは新しいスコープを作る
+	p.enterScope()
+	defer p.leaveScope()
+
+	// 次のトークンへ進める
+	p.nextToken()
+
+	// 次のトークンがRBRACEでない場合は繰り返す
+	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+
+		// Statementを構文解析
+		stmt := p.parseStatement()
+
+		// stmtがnilでなければ、block.Statementsに追加
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+
+		// 次のトークンへ進める
+		p.nextToken()
+	}
+
+	return block
+}
+
+/**
+ * 名前: Parser.parseFunctionLiteral
+ * 概要: 関数リテラルを構文解析する
+ * 引数: なし
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+
+	// 関数リテラルを持つast.FunctionLiteralポインタを生成
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+
+	// 次のトークンがLPARENでなければnilを返す
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	// 関数のパラメータを構文解析
+	lit.Parameters = p.parseFunctionParameters()
+
+	// 戻り値の型注釈 ( -> int ) を構文解析
+	if !p.parseOptionalReturnType(lit) {
+		return nil
+	}
+
+	// 次のトークンがLBRACEでなければnilを返す
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	// パラメータは関数の本体を囲むスコープで宣言する
+	p.enterScope()
+	defer p.leaveScope()
+
+	for _, param := range lit.Parameters {
+		p.declare(param.Value, false)
+	}
+
+	// 関数の本体を構文解析
+	lit.Body = p.parseBlockStatement()
+
+	return lit
+}
+
+/**
+ * 名前: Parser.isArrowParameterList
+ * 概要: 現在のLPARENから始まるトークン列が、アロー関数のパラメータリスト ( (a, b) => ) かどうかを先読みして判定する
+ *  字句解析器の状態を複製して先読みするため、構文解析器の状態は変わらない
+ * 引数: なし
+ * 戻値: bool
+ */
+func (p *Parser) isArrowParameterList() bool {
+
+	l := *p.l
+	tok := p.peekToken
+
+	// () => であればパラメータが無いアロー関数
+	if tok.Type != token.RPAREN {
+
+		for {
+
+			if tok.Type != token.IDENT {
+				return false
+			}
+
+			tok = l.NextToken()
+
+			// 型注釈は , か ) まで読み飛ばす
+			if tok.Type == token.COLON {
+				tok = skipTypeAnnotation(&l)
+			}
+
+			if tok.Type != token.COMMA {
+				break
+			}
+
+			tok = l.NextToken()
+		}
+
+		if tok.Type != token.RPAREN {
+			return false
+		}
+	}
+
+	tok = l.NextToken()
+
+	return tok.Type == token.ARROW
+}
+
+/**
+ * 名前: skipTypeAnnotation
+ * 概要: 先読み用の字句解析器で、型注釈を読み飛ばす
+ *  括弧の外にある , または ) を見つけるまでトークンを読み進める
+ * 引数: 字句解析器
+ * 戻値: 型注釈の次のトークン
+ */
+func skipTypeAnnotation(l *lexer.Lexer) token.Token {
+
+	depth := 0
+
+	for {
+
+		tok := l.NextToken()
+
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET:
+			depth++
+		case token.RBRACKET:
+			depth--
+		case token.RPAREN:
+			if depth == 0 {
+				return tok
+			}
+			depth--
+		case token.COMMA:
+			if depth == 0 {
+				return tok
+			}
+		case token.EOF:
+			return tok
+		}
+	}
+}
+
+/**
+ * 名前: Parser.parseOptionalType
+ * 概要: 名前の後に : があれば型注釈を構文解析し、名前に設定する
+ * 引数: 型注釈を設定する名前
+ * 戻値: 構文解析に成功したかどうか
+ */
+func (p *Parser) parseOptionalType(ident *ast.Identifier) bool {
+
+	if !p.peekTokenIs(token.COLON) {
+		return true
+	}
+
+	p.nextToken()
+	p.nextToken()
+
+	ident.Type = p.parseTypeAnnotation()
+
+	return ident.Type != nil
+}
+
+/**
+ * 名前: Parser.parseOptionalReturnType
+ * 概要: パラメータリストの後に -> があれば戻り値の型注釈を構文解析し、関数リテラルに設定する
+ * 引数: 関数リテラル
+ * 戻値: 構文解析に成功したかどうか
+ */
+func (p *Parser) parseOptionalReturnType(lit *ast.FunctionLiteral) bool {
+
+	if !p.peekTokenIs(token.RARROW) {
+		return true
+	}
+
+	p.nextToken()
+	p.nextToken()
+
+	lit.ReturnType = p.parseTypeAnnotation()
+
+	return lit.ReturnType != nil
+}
+
+/**
+ * 名前: Parser.parseTypeAnnotation
+ * 概要: 型注釈を構文解析する
+ *  型名 ( int, Point ), 型引数つきの型 ( array[int], hash[string, int] ), 関数型 ( fn(int) -> int )
+ * 引数: なし
+ * 戻値: *ast.TypeAnnotation
+ */
+func (p *Parser) parseTypeAnnotation() *ast.TypeAnnotation {
+
+	ta := &ast.TypeAnnotation{Token: p.curToken, Name: p.curToken.Literal}
+
+	switch p.curToken.Type {
+	case token.IDENT, token.NULL:
+		if !p.peekTokenIs(token.LBRACKET) {
+			return ta
+		}
+
+		p.nextToken()
+
+		ta.Params = p.parseTypeList(token.RBRACKET)
+
+		if ta.Params == nil {
+			return nil
+		}
+	case token.FUNCTION:
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+
+		ta.Params = p.parseTypeList(token.RPAREN)
+
+		if ta.Params == nil {
+			return nil
+		}
+
+		if p.peekTokenIs(token.RARROW) {
+
+			p.nextToken()
+			p.nextToken()
+
+			ta.Return = p.parseTypeAnnotation()
+
+			if ta.Return == nil {
+				return nil
+			}
+		}
+	default:
+		msg := fmt.Sprintf("expected type, got %s instead", p.curToken.Type)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	return ta
+}
+
+/**
+ * 名前: Parser.parseTypeList
+ * 概要: カンマで区切られた型注釈のリストを、終端のトークンまで構文解析する
+ * 引数: 終端のトークンの種類
+ * 戻値: []*ast.TypeAnnotation : 失敗した場合は nil
+ */
+func (p *Parser) parseTypeList(end token.TokenType) []*ast.TypeAnnotation {
+
+	list := []*ast.TypeAnnotation{}
+
+	for !p.peekTokenIs(end) {
+
+		p.nextToken()
+
+		ta := p.parseTypeAnnotation()
+
+		if ta == nil {
+			return nil
+		}
+
+		list = append(list, ta)
+
+		if !p.peekTokenIs(end) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+
+	return list
+}
+
+/**
+ * 名前: Parser.parseArrowFunction
+ * 概要: アロー関数の => より後ろを構文解析する
+ *  本体がブロックでなければ、式を1つだけ含むブロックとする
+ * 引数: パラメータリスト
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseArrowFunction(params []*ast.Identifier) ast.Expression {
+
+	if !p.expectPeek(token.ARROW) {
+		return nil
+	}
+
+	lit := &ast.FunctionLiteral{Token: p.curToken, Parameters: params}
+
+	// パラメータは関数の本体を囲むスコープで宣言する
+	p.enterScope()
+	defer p.leaveScope()
+
+	for _, param := range params {
+		p.declare(param.Value, false)
+	}
+
+	if p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		lit.Body = p.parseBlockStatement()
+		return lit
+	}
+
+	p.nextToken()
+
+	stmt := &ast.ExpressionStatement{Token: p.curToken}
+	stmt.Expression = p.parseExpression(LOWEST)
+
+	lit.Body = &ast.BlockStatement{Token: lit.Token, Statements: []ast.Statement{stmt}}
+
+	return lit
+}
+
+/**
+ * 名前: Parser.parseFunctionParameters
+ * 概要: 関数のパラメータを構文解析する
+ * 引数: なし
+ * 戻値: []*ast.Identifier
+ */
+func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+
+	// パラメータリスト
+	identifiers := []*ast.Identifier{}
+
+	// 次のトークンがRPARENであれば、nilを返す
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return identifiers
+	}
+
+	// 次のトークンへ進める
+	p.nextToken()
+
+	// IDENTを持つast.Identifierを生成
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	identifiers = append(identifiers, ident)
+
+	if !p.parseOptionalType(ident) {
+		return nil
+	}
+
+	// 次のトークンがCOMMAであれば、繰り返す
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		identifiers = append(identifiers, ident)
+
+		if !p.parseOptionalType(ident) {
+			return nil
+		}
+	}
+
+	// 次のトークンがRPARENでなければnilを返す
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return identifiers
+}
+
+/**
+ * 名前: Parser.parseCallExpression
+ * 概要: 呼び出し式を構文解析する
+ * 引数: ast.Expression
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+
+	exp := &ast.CallExpression{Token: p.curToken, Function: function}
+
+	exp.Arguments = p.parseExpressionList(token.RPAREN)
+
+	return exp
+}
+
+/**
+ * 名前: Parser.parseCallArguments
+ * 概要: 引数リストを構文解析する
+ * 引数: なし
+ * 戻値: []ast.Expression
+ */
+func (p *Parser) parseCallArguments() []ast.Expression {
+
+	args := []ast.Expression{}
+
+	// 次のトークンがRPARENであれば、nilを返す
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return args
+	}
+
+	// 次のトークンへ進める
+	p.nextToken()
+
+	// 式を構文解析
+	args = append(args, p.parseExpression(LOWEST))
+
+	// 次のトークンがCOMMAであれば、繰り返す
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		args = append(args, p.parseExpression(LOWEST))
+	}
+
+	// 次のトークンがRPARENでなければnilを返す
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return args
+
+}
+
+/**
+ * 名前: Parser.parseStringLiteral
+ * 概要: 文字列リテラルを構文解析する
+ * 引数: なし
+ * 戻値: []ast.Expression
+ */
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+/**
+ * 名前: Parser.parseBytesLiteral
+ * 概要: バイト列リテラルを構文解析する
+ *  \xNN, \n, \r, \t, \0, \\, \" のエスケープシーケンスを解釈する
+ * 引数: なし
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseBytesLiteral() ast.Expression {
+
+	literal := &ast.BytesLiteral{Token: p.curToken}
+	source := p.curToken.Literal
+	value := []byte{}
+
+	for i := 0; i < len(source); i++ {
+
+		if source[i] != '\\' {
+			value = append(value, source[i])
+			continue
+		}
+
+		if i+1 >= len(source) {
+			p.errors = append(p.errors, "unterminated escape sequence in bytes literal")
+			return nil
+		}
+
+		i++
+
+		switch source[i] {
+		case 'n':
+			value = append(value, '\n')
+		case 'r':
+			value = append(value, '\r')
+		case 't':
+			value = append(value, '\t')
+		case '0':
+			value = append(value, 0)
+		case '\\', '"':
+			value = append(value, source[i])
+		case 'x':
+			if i+2 >= len(source) {
+				p.errors = append(p.errors, "invalid escape sequence in bytes literal: \\"+source[i:])
+				return nil
+			}
+
+			b, err := strconv.ParseUint(source[i+1:i+3], 16, 8)
+
+			if err != nil {
+				p.errors = append(p.errors, "invalid escape sequence in bytes literal: \\"+source[i:i+3])
+				return nil
+			}
+
+			value = append(value, byte(b))
+			i += 2
+		default:
+			msg := fmt.Sprintf("invalid escape sequence in bytes literal: \\%c", source[i])
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+	}
+
+	literal.Value = value
+
+	return literal
+}
+
+/**
+ * 名前: Parser.parseArrayLiteral
+ * 概要: 配列リテラルを構文解析する
+ * 引数: なし
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseArrayLiteral() ast.Expression {
+
+	array := &ast.ArrayLiteral{Token: p.curToken}
+
+	// 次のトークンがRBRACKETであれば、空の配列とする
+	if p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		array.Elements = []ast.Expression{}
+		return array
+	}
+
+	noArrow := p.noArrow
+	p.noArrow = false
+	defer func() { p.noArrow = noArrow }()
+
+	p.nextToken()
+
+	held := p.holdConstAssignments()
+
+	first := p.parseExpression(LOWEST)
+
+	// [expr for ...] であれば、配列の内包表記とする
+	if p.peekTokenIs(token.FOR) {
+		return p.parseListComprehension(array.Token, first, held)
+	}
+
+	p.releaseConstAssignments(held)
+
+	array.Elements = p.parseExpressionListRest([]ast.Expression{first}, token.RBRACKET)
+
+	return array
+}
 
 /**
- * 名前: Parser.parseBoolean
- * 概要: 真偽値を構文解析する
+ * 名前: Parser.parseSetLiteral
+ * 概要: セットリテラルを構文解析する
  * 引数: なし
  * 戻値: ast.Expression
  */
-func (p *Parser) parseBoolean() ast.Expression {
-	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
+func (p *Parser) parseSetLiteral() ast.Expression {
+
+	set := &ast.SetLiteral{Token: p.curToken}
+
+	set.Elements = p.parseExpressionList(token.RBRACE)
+
+	return set
 }
 
 /**
- * 名前: Parser.parseGroupedExpression
- * 概要:
+ * 名前: Parser.parseListComprehension
+ * 概要: 配列の内包表記のfor節以降を構文解析する
+ * 引数: '[' トークン, 要素の式, 要素の中で判定を待っているconstへの代入
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseListComprehension(tok token.Token, element ast.Expression, held *heldAssignments) ast.Expression {
+
+	comprehension := &ast.ListComprehension{Token: tok, Element: element}
+
+	// 反復する変数は内包表記の中だけで有効
+	p.enterScope()
+	defer p.leaveScope()
+
+	comprehension.Clauses = p.parseComprehensionClauses()
+
+	// 反復する変数を宣言してから、要素の中の代入を判定する
+	p.releaseConstAssignments(held)
+
+	if comprehension.Clauses == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return comprehension
+}
+
+/**
+ * 名前: Parser.parseHashComprehension
+ * 概要: ハッシュの内包表記のfor節以降を構文解析する
+ * 引数: '{' トークン, キーの式, 値の式, キーと値の中で判定を待っているconstへの代入
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseHashComprehension(tok token.Token, key, value ast.Expression, held *heldAssignments) ast.Expression {
+
+	comprehension := &ast.HashComprehension{Token: tok, Key: key, Value: value}
+
+	// 反復する変数は内包表記の中だけで有効
+	p.enterScope()
+	defer p.leaveScope()
+
+	comprehension.Clauses = p.parseComprehensionClauses()
+
+	// 反復する変数を宣言してから、キーと値の中の代入を判定する
+	p.releaseConstAssignments(held)
+
+	if comprehension.Clauses == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return comprehension
+}
+
+/**
+ * 名前: Parser.parseComprehensionClauses
+ * 概要: 内包表記のfor節 ( for x, y in xs if cond ) を続く限り構文解析する
  * 引数: なし
+ * 戻値: []*ast.ComprehensionClause
+ */
+func (p *Parser) parseComprehensionClauses() []*ast.ComprehensionClause {
+
+	clauses := []*ast.ComprehensionClause{}
+
+	for p.peekTokenIs(token.FOR) {
+
+		p.nextToken()
+
+		clause := &ast.ComprehensionClause{Token: p.curToken}
+
+		// 反復する変数を構文解析
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		clause.Variables = []*ast.Identifier{{Token: p.curToken, Value: p.curToken.Literal}}
+
+		for p.peekTokenIs(token.COMMA) {
+
+			p.nextToken()
+
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+
+			clause.Variables = append(clause.Variables, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		}
+
+		if !p.expectPeek(token.IN) {
+			return nil
+		}
+
+		// 反復対象の式を構文解析
+		p.nextToken()
+
+		clause.Iterable = p.parseExpression(LOWEST)
+
+		for _, v := range clause.Variables {
+			p.declare(v.Value, false)
+		}
+
+		// 次のトークンがIFであれば、絞り込みの条件を構文解析
+		for p.peekTokenIs(token.IF) {
+			p.nextToken()
+			p.nextToken()
+			clause.Conditions = append(clause.Conditions, p.parseExpression(LOWEST))
+		}
+
+		clauses = append(clauses, clause)
+	}
+
+	return clauses
+}
+
+/**
+ * 名前: Parser.parseExpressionList
+ * 概要: 式リストを構文解析する
+ * 引数: token.TokenType
+ * 戻値: []ast.Expression
+ */
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+
+	list := []ast.Expression{}
+
+	// 括弧の中の => は腕の区切りにならないので、アロー関数を構文解析する
+	noArrow := p.noArrow
+	p.noArrow = false
+	defer func() { p.noArrow = noArrow }()
+
+	// 次のトークンがendであれば、nilを返す
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list
+	}
+
+	// 次のトークンへ進める
+	p.nextToken()
+
+	// 式を構文解析
+	list = append(list, p.parseExpression(LOWEST))
+
+	return p.parseExpressionListRest(list, end)
+}
+
+/**
+ * 名前: Parser.parseExpressionListRest
+ * 概要: 式リストの2つ目以降の要素を構文解析する
+ * 引数: 構文解析済みの要素, token.TokenType
+ * 戻値: []ast.Expression
+ */
+func (p *Parser) parseExpressionListRest(list []ast.Expression, end token.TokenType) []ast.Expression {
+
+	// 次のトークンがCOMMAであれば、繰り返す
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(LOWEST))
+	}
+
+	// 次のトークンがendでなければnilを返す
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
+/**
+ * 名前: Parser.parseIndexExpression
+ * 概要: インデックス式とスライス式を構文解析する
+ * 引数: ast.Expression
  * 戻値: ast.Expression
  */
-func (p *Parser) parseGroupedExpression() ast.Expression {
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+
+	exp := &ast.IndexExpression{
+		Token:    p.curToken,
+		Left:     left,
+		Optional: p.curTokenIs(token.OPTIONAL_LBRACKET),
+	}
 
+	// 次のトークンへ進める
 	p.nextToken()
 
-	exp := p.parseExpression(LOWEST)
+	// [:end] のように開始位置を省略したスライス式
+	if p.curTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp, nil)
+	}
 
-	if !p.expectPeek(token.RPAREN) {
+	// インデックスを構文解析
+	exp.Index = p.parseExpression(LOWEST)
+
+	// 次のトークンがCOLONであれば、スライス式とする
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		return p.parseSliceExpression(exp, exp.Index)
+	}
+
+	// 次のトークンがRBRACKETでなければnilを返す
+	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
 
 	return exp
+
 }
 
 /**
- * 名前: Parser.parseIfExpression
- * 概要: if文を構文解析する
+ * 名前: Parser.parseSliceExpression
+ * 概要: 最初のCOLONより後ろのスライス式 ( [start:end:step] ) を構文解析する
+ * 引数: 構文解析中のインデックス式, 開始位置の式
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseSliceExpression(index *ast.IndexExpression, start ast.Expression) ast.Expression {
+
+	exp := &ast.SliceExpression{
+		Token:    index.Token,
+		Left:     index.Left,
+		Start:    start,
+		Optional: index.Optional,
+	}
+
+	// 終了位置を構文解析
+	if !p.peekTokenIs(token.COLON) && !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		exp.End = p.parseExpression(LOWEST)
+	}
+
+	// 次のトークンがCOLONであれば、増分を構文解析
+	if p.peekTokenIs(token.COLON) {
+
+		p.nextToken()
+
+		if !p.peekTokenIs(token.RBRACKET) {
+			p.nextToken()
+			exp.Step = p.parseExpression(LOWEST)
+		}
+	}
+
+	// 次のトークンがRBRACKETでなければnilを返す
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return exp
+}
+
+/**
+ * 名前: Parser.parseMemberExpression
+ * 概要: メンバアクセス式を構文解析する
+ * 引数: ast.Expression
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+
+	exp := &ast.MemberExpression{
+		Token:    p.curToken,
+		Object:   object,
+		Optional: p.curTokenIs(token.OPTIONAL_DOT),
+	}
+
+	// 次のトークンがIDENTでなければnilを返す
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
+/**
+ * 名前: Parser.parseNullLiteral
+ * 概要: nullリテラルを構文解析する
  * 引数: なし
  * 戻値: ast.Expression
  */
-func (p *Parser) parseIfExpression() ast.Expression {
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
 
-	expression := &ast.IfExpression{Token: p.curToken}
+/**
+ * 名前: Parser.parseHashLiteral
+ * 概要: ハッシュリテラルを構文解析する
+ * 引数: ast.Expression
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseHashLiteral() ast.Expression {
+
+	hash := &ast.HashLiteral{Token: p.curToken}
+
+	hash.Pairs = make(map[ast.Expression]ast.Expression)
+
+	for !p.peekTokenIs(token.RBRACE) {
+
+		p.nextToken()
+
+		held := p.holdConstAssignments()
+
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			p.releaseConstAssignments(held)
+			return nil
+		}
+
+		p.nextToken()
+
+		value := p.parseExpression(LOWEST)
+
+		// {k: v for ...} であれば、ハッシュの内包表記とする
+		if len(hash.Pairs) == 0 && p.peekTokenIs(token.FOR) {
+			return p.parseHashComprehension(hash.Token, key, value, held)
+		}
+
+		p.releaseConstAssignments(held)
+
+		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return hash
+}
+
+/**
+ * 名前: Parser.parseMatchExpression
+ * 概要: match式を構文解析する
+ * 引数: なし
+ * 戻値: ast.Expression
+ */
+func (p *Parser) parseMatchExpression() ast.Expression {
+
+	expression := &ast.MatchExpression{Token: p.curToken}
 
-	// 次のトークンへ進める
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
 
-	// 条件式を構文解析
+	// 照合対象の式を構文解析
 	p.nextToken()
 
-	expression.Condition = p.parseExpression(LOWEST)
+	expression.Subject = p.parseExpression(LOWEST)
 
 	if !p.expectPeek(token.RPAREN) {
 		return nil
@@ -520,286 +2197,320 @@ func (p *Parser) parseIfExpression() ast.Expression {
 		return nil
 	}
 
-	// Consequenceを構文解析
-	expression.Consequence = p.parseBlockStatement()
+	expression.Arms = []*ast.MatchArm{}
 
-	// 次のトークンがELSEであれば、ELSEブロックを構文解析
-	if p.peekTokenIs(token.ELSE) {
+	// 次のトークンがRBRACEでない場合は、腕を構文解析する
+	for !p.peekTokenIs(token.RBRACE) {
 
 		p.nextToken()
 
-		if !p.expectPeek(token.LBRACE) {
+		arm := p.parseMatchArm()
+
+		if arm == nil {
 			return nil
 		}
 
-		// Alternativeを構文解析
-		expression.Alternative = p.parseBlockStatement()
+		expression.Arms = append(expression.Arms, arm)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
 	}
 
 	return expression
 }
 
 /**
- * 名前: Parser.parseBlockStatement
- * 概要: ブロック文を構文解析する
+ * 名前: Parser.parseMatchArm
+ * 概要: match式の腕 ( pattern [if guard] => expr ) を構文解析する
  * 引数: なし
- * 戻値: *ast.BlockStatement
+ * 戻値: *ast.MatchArm
  */
-func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+func (p *Parser) parseMatchArm() *ast.MatchArm {
 
-	block := &ast.BlockStatement{Token: p.curToken}
+	arm := &ast.MatchArm{Token: p.curToken}
 
-	// Statementsにast.Statementを追加していく
-	block.Statements = []ast.Statement{}
+	// パターンで束縛した変数は腕の中だけで有効
+	p.enterScope()
+	defer p.leaveScope()
 
-	// 次のトークンへ進める
-	p.nextToken()
+	arm.Pattern = p.parsePattern()
 
-	// 次のトークンがRBRACEでない場合は繰り返す
-	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+	if arm.Pattern == nil {
+		return nil
+	}
 
-		// Statementを構文解析
-		stmt := p.parseStatement()
+	// 次のトークンがIFであれば、ガード条件を構文解析
+	// .. ガード条件の後ろの => はアロー関数ではなく腕の区切りとする
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		p.nextToken()
 
-		// stmtがnilでなければ、block.Statementsに追加
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
-		}
+		noArrow := p.noArrow
+		p.noArrow = true
+		arm.Guard = p.parseExpression(LOWEST)
+		p.noArrow = noArrow
+	}
 
-		// 次のトークンへ進める
-		p.nextToken()
+	if !p.expectPeek(token.ARROW) {
+		return nil
 	}
 
-	return block
+	p.nextToken()
+
+	arm.Body = p.parseExpression(LOWEST)
+
+	return arm
 }
 
 /**
- * 名前: Parser.parseFunctionLiteral
- * 概要: 関数リテラルを構文解析する
+ * 名前: Parser.parsePattern
+ * 概要: match式のパターンを構文解析する
  * 引数: なし
- * 戻値: ast.Expression
+ * 戻値: ast.Pattern
  */
-func (p *Parser) parseFunctionLiteral() ast.Expression {
-
-	// 関数リテラルを持つast.FunctionLiteralポインタを生成
-	lit := &ast.FunctionLiteral{Token: p.curToken}
+func (p *Parser) parsePattern() ast.Pattern {
 
-	// 次のトークンがLPARENでなければnilを返す
-	if !p.expectPeek(token.LPAREN) {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		if p.peekTokenIs(token.DOT) {
+			return p.parseEnumPattern()
+		}
+		p.declare(p.curToken.Literal, false)
+		return &ast.BindingPattern{
+			Token: p.curToken,
+			Name:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		}
+	case token.INT, token.STRING, token.TRUE, token.FALSE, token.NULL, token.MINUS:
+		return p.parseLiteralPattern()
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LPAREN:
+		return p.parseTuplePattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	default:
+		msg := fmt.Sprintf("unexpected token in pattern: %s", p.curToken.Type)
+		p.errors = append(p.errors, msg)
 		return nil
 	}
+}
 
-	// 関数のパラメータを構文解析
-	lit.Parameters = p.parseFunctionParameters()
+/**
+ * 名前: Parser.parseLiteralPattern
+ * 概要: リテラルパターンを構文解析する
+ * 引数: なし
+ * 戻値: ast.Pattern
+ */
+func (p *Parser) parseLiteralPattern() ast.Pattern {
 
-	// 次のトークンがLBRACEでなければnilを返す
-	if !p.expectPeek(token.LBRACE) {
+	pattern := &ast.LiteralPattern{Token: p.curToken}
+
+	switch p.curToken.Type {
+	case token.INT, token.STRING, token.TRUE, token.FALSE, token.NULL, token.MINUS:
+	default:
+		msg := fmt.Sprintf("expected literal in pattern, got %s instead", p.curToken.Type)
+		p.errors = append(p.errors, msg)
 		return nil
 	}
 
-	// 関数の本体を構文解析
-	lit.Body = p.parseBlockStatement()
+	// 負の整数は前置演算子として構文解析する
+	if p.curTokenIs(token.MINUS) {
 
-	return lit
+		if !p.peekTokenIs(token.INT) {
+			p.peekError(token.INT)
+			return nil
+		}
+
+		pattern.Value = p.parsePrefixExpression()
+	} else {
+		pattern.Value = p.prefixParseFns[p.curToken.Type]()
+	}
+
+	if pattern.Value == nil {
+		return nil
+	}
+
+	return pattern
 }
 
 /**
- * 名前: Parser.parseFunctionParameters
- * 概要: 関数のパラメータを構文解析する
+ * 名前: Parser.parseArrayPattern
+ * 概要: 配列パターンを構文解析する
  * 引数: なし
- * 戻値: []*ast.Identifier
+ * 戻値: ast.Pattern
  */
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+func (p *Parser) parseArrayPattern() ast.Pattern {
 
-	// パラメータリスト
-	identifiers := []*ast.Identifier{}
+	pattern := &ast.ArrayPattern{Token: p.curToken, Elements: []ast.Pattern{}}
+
+	for !p.peekTokenIs(token.RBRACKET) {
 
-	// 次のトークンがRPARENであれば、nilを返す
-	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifiers
-	}
 
-	// 次のトークンへ進める
-	p.nextToken()
+		// ...rest は残りの要素を束縛する。配列パターンの最後にしか書けない
+		if p.curTokenIs(token.ELLIPSIS) {
 
-	// IDENTを持つast.Identifierを生成
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
 
-	// 次のトークンがCOMMAであれば、繰り返す
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
-		p.nextToken()
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		identifiers = append(identifiers, ident)
+			pattern.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			p.declare(pattern.Rest.Value, false)
+
+			break
+		}
+
+		element := p.parsePattern()
+
+		if element == nil {
+			return nil
+		}
+
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !p.peekTokenIs(token.RBRACKET) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
 	}
 
-	// 次のトークンがRPARENでなければnilを返す
-	if !p.expectPeek(token.RPAREN) {
+	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
 
-	return identifiers
+	return pattern
 }
 
 /**
- * 名前: Parser.parseCallExpression
- * 概要: 呼び出し式を構文解析する
- * 引数: ast.Expression
- * 戻値: ast.Expression
+ * 名前: Parser.parseTuplePattern
+ * 概要: タプルパターンを構文解析する
+ *  カンマを含まない (p) は、括弧でくくったパターン p とする
+ * 引数: なし
+ * 戻値: ast.Pattern
  */
-func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+func (p *Parser) parseTuplePattern() ast.Pattern {
 
-	exp := &ast.CallExpression{Token: p.curToken, Function: function}
+	pattern := &ast.TuplePattern{Token: p.curToken, Elements: []ast.Pattern{}}
+	comma := false
 
-	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	for !p.peekTokenIs(token.RPAREN) {
 
-	return exp
-}
+		p.nextToken()
 
-/**
- * 名前: Parser.parseCallArguments
- * 概要: 引数リストを構文解析する
- * 引数: なし
- * 戻値: []ast.Expression
- */
-func (p *Parser) parseCallArguments() []ast.Expression {
+		element := p.parsePattern()
 
-	args := []ast.Expression{}
+		if element == nil {
+			return nil
+		}
 
-	// 次のトークンがRPARENであれば、nilを返す
-	if p.peekTokenIs(token.RPAREN) {
-		p.nextToken()
-		return args
-	}
+		pattern.Elements = append(pattern.Elements, element)
 
-	// 次のトークンへ進める
-	p.nextToken()
+		if p.peekTokenIs(token.RPAREN) {
+			break
+		}
 
-	// 式を構文解析
-	args = append(args, p.parseExpression(LOWEST))
+		if !p.expectPeek(token.COMMA) {
+			return nil
+		}
 
-	// 次のトークンがCOMMAであれば、繰り返す
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
-		p.nextToken()
-		args = append(args, p.parseExpression(LOWEST))
+		comma = true
 	}
 
-	// 次のトークンがRPARENでなければnilを返す
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
 
-	return args
-
-}
+	if len(pattern.Elements) == 1 && !comma {
+		return pattern.Elements[0]
+	}
 
-/**
- * 名前: Parser.parseStringLiteral
- * 概要: 文字列リテラルを構文解析する
- * 引数: なし
- * 戻値: []ast.Expression
- */
-func (p *Parser) parseStringLiteral() ast.Expression {
-	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+	return pattern
 }
 
 /**
- * 名前: Parser.parseArrayLiteral
- * 概要: 配列リテラルを構文解析する
+ * 名前: Parser.parseEnumPattern
+ * 概要: 列挙型のパターン ( Status.Done(r) ) を構文解析する
  * 引数: なし
- * 戻値: ast.Expression
+ * 戻値: ast.Pattern
  */
-func (p *Parser) parseArrayLiteral() ast.Expression {
+func (p *Parser) parseEnumPattern() ast.Pattern {
 
-	array := &ast.ArrayLiteral{Token: p.curToken}
-
-	array.Elements = p.parseExpressionList(token.RBRACKET)
+	pattern := &ast.EnumPattern{
+		Token: p.curToken,
+		Enum:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+	}
 
-	return array
-}
+	p.nextToken()
 
-/**
- * 名前: Parser.parseExpressionList
- * 概要: 式リストを構文解析する
- * 引数: token.TokenType
- * 戻値: []ast.Expression
- */
-func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
 
-	list := []ast.Expression{}
+	pattern.Variant = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	// 次のトークンがendであれば、nilを返す
-	if p.peekTokenIs(end) {
-		p.nextToken()
-		return list
+	if !p.peekTokenIs(token.LPAREN) {
+		return pattern
 	}
 
-	// 次のトークンへ進める
 	p.nextToken()
 
-	// 式を構文解析
-	list = append(list, p.parseExpression(LOWEST))
-
-	// 次のトークンがCOMMAであれば、繰り返す
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
-		p.nextToken()
-		list = append(list, p.parseExpression(LOWEST))
-	}
+	pattern.Payload = []ast.Pattern{}
 
-	// 次のトークンがendでなければnilを返す
-	if !p.expectPeek(end) {
-		return nil
-	}
+	for !p.peekTokenIs(token.RPAREN) {
 
-	return list
-}
+		p.nextToken()
 
-/**
- * 名前: Parser.parseIndexExpression
- * 概要: インデックス式を構文解析する
- * 引数: ast.Expression
- * 戻値: ast.Expression
- */
-func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+		element := p.parsePattern()
 
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+		if element == nil {
+			return nil
+		}
 
-	// 次のトークンへ進める
-	p.nextToken()
+		pattern.Payload = append(pattern.Payload, element)
 
-	// インデックスを構文解析
-	exp.Index = p.parseExpression(LOWEST)
+		if !p.peekTokenIs(token.RPAREN) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
 
-	// 次のトークンがRBRACKETでなければnilを返す
-	if !p.expectPeek(token.RBRACKET) {
+	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
 
-	return exp
-
+	return pattern
 }
 
 /**
- * 名前: Parser.parseHashLiteral
- * 概要: ハッシュリテラルを構文解析する
- * 引数: ast.Expression
- * 戻値: ast.Expression
+ * 名前: Parser.parseHashPattern
+ * 概要: ハッシュパターンを構文解析する
+ * 引数: なし
+ * 戻値: ast.Pattern
  */
-func (p *Parser) parseHashLiteral() ast.Expression {
+func (p *Parser) parseHashPattern() ast.Pattern {
 
-	hash := &ast.HashLiteral{Token: p.curToken}
-
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
+	pattern := &ast.HashPattern{
+		Token:  p.curToken,
+		Keys:   []ast.Expression{},
+		Values: []ast.Pattern{},
+	}
 
 	for !p.peekTokenIs(token.RBRACE) {
 
 		p.nextToken()
 
-		key := p.parseExpression(LOWEST)
+		// キーはリテラルに限る
+		key := p.parseLiteralPattern()
+
+		if key == nil {
+			return nil
+		}
 
 		if !p.expectPeek(token.COLON) {
 			return nil
@@ -807,21 +2518,25 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 
 		p.nextToken()
 
-		value := p.parseExpression(LOWEST)
+		value := p.parsePattern()
 
-		hash.Pairs[key] = value
+		if value == nil {
+			return nil
+		}
+
+		pattern.Keys = append(pattern.Keys, key.(*ast.LiteralPattern).Value)
+		pattern.Values = append(pattern.Values, value)
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
 		}
-
 	}
 
 	if !p.expectPeek(token.RBRACE) {
 		return nil
 	}
 
-	return hash
+	return pattern
 }
 
 /**
@@ -856,6 +2571,9 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 
+	// nullリテラルの構文解析
+	p.registerPrefix(token.NULL, p.parseNullLiteral)
+
 	// LPARENトークンを前置構文解析関数のマップに登録
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 
@@ -865,6 +2583,9 @@ func New(l *lexer.Lexer) *Parser {
 	// 文字列リテラルの構文解析
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 
+	// バイト列リテラルの構文解析
+	p.registerPrefix(token.BYTES, p.parseBytesLiteral)
+
 	// fn (関数リテラル)の構文解析
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 
@@ -874,6 +2595,15 @@ func New(l *lexer.Lexer) *Parser {
 	// ハッシュリテラルの構文解析
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
+	// セットリテラルの構文解析
+	p.registerPrefix(token.SET_LBRACE, p.parseSetLiteral)
+
+	// match式の構文解析
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+
+	// try式の構文解析
+	p.registerPrefix(token.TRY, p.parseTryExpression)
+
 	// 中間構文解析関数のマップを初期化
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 
@@ -885,8 +2615,18 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.IN, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
+	p.registerInfix(token.PIPELINE, p.parsePipeExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+
+	// 最も外側のスコープ
+	p.enterScope()
+	p.registerInfix(token.OPTIONAL_LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.OPTIONAL_DOT, p.parseMemberExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
 
 	p.nextToken()
 	p.nextToken()
@@ -7,6 +7,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/MasaruFukazawa/monkey-lang/src/ast"
@@ -367,6 +368,34 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		{"add(a + b + c * d / f + g)", "add((((a + b) + ((c * d) / f)) + g))"},
 		{"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
 		{"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{"a ?? b == c", "(a ?? (b == c))"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{`a?["b"]?.c ?? null`, "(((a?[b])?.c) ?? null)"},
+		{"xs |> map(f) |> filter(g) |> sum()", "(((xs |> map(f)) |> filter(g)) |> sum())"},
+		{"a + b |> f(c * d)", "((a + b) |> f((c * d)))"},
+		{"a ?? b |> f", "((a ?? b) |> f)"},
+		{"a.b.c", "((a.b).c)"},
+		{"a.b(c) + d", "((a.b)(c) + d)"},
+		{"-a.b", "(-(a.b))"},
+		{"a.b[1]?.c", "(((a.b)[1])?.c)"},
+		{"xs |> a.b(1)", "(xs |> (a.b)(1))"},
+		{"a[1:2]", "(a[1:2])"},
+		{"a[:b + 1]", "(a[:(b + 1)])"},
+		{"a[1:]", "(a[1:])"},
+		{"a[:]", "(a[:])"},
+		{"a[::-1]", "(a[::(-1)])"},
+		{"a[1:5:2][0]", "((a[1:5:2])[0])"},
+		{"a?[1:]", "(a?[1:])"},
+		{"a = b + 1", "(a = (b + 1))"},
+		{"a = b = c", "(a = (b = c))"},
+		{"a = b |> f()", "(a = (b |> f()))"},
+		{"x => x * 2", "(x) => (x * 2)"},
+		{"(a, b) => a + b", "(a, b) => (a + b)"},
+		{"() => 1", "() => 1"},
+		{"(a) => { a }", "(a) => a"},
+		{"map(xs, x => x * 2)", "map(xs, (x) => (x * 2))"},
+		{"f = x => y => x + y", "(f = (x) => (y) => (x + y))"},
+		{"(a) * 2", "(a * 2)"},
 	}
 
 	for _, tt := range tests {
@@ -1002,6 +1031,21 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		testIntegerLiteral(t, value, expectedValue)
 
 	}
+
+	// キーは記述した順に並ぶ
+	keys := []string{}
+
+	for _, key := range hash.Keys {
+		keys = append(keys, key.String())
+	}
+
+	if strings.Join(keys, ",") != "one,two,three" {
+		t.Errorf("hash.Keys has wrong order. got=%v", keys)
+	}
+
+	if hash.String() != "{one:1, two:2, three:3}" {
+		t.Errorf("hash.String() wrong. got=%q", hash.String())
+	}
 }
 
 /**
@@ -1091,3 +1135,750 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		testFunc(value)
 	}
 }
+
+/**
+ * 名前: TestParsingMatchExpression
+ * 概要: match式の解析テストを実装する
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestParsingMatchExpression(t *testing.T) {
+
+	input := `match (x) { 1 => "one", [a, ...rest] if a > 0 => a, {"k": _} => 2, _ => 0 }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.MatchExpression)
+
+	if !ok {
+		t.Fatalf("exp is not ast.MatchExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, exp.Subject, "x") {
+		return
+	}
+
+	if len(exp.Arms) != 4 {
+		t.Fatalf("exp.Arms has wrong length. got=%d", len(exp.Arms))
+	}
+
+	if _, ok := exp.Arms[0].Pattern.(*ast.LiteralPattern); !ok {
+		t.Errorf("Arms[0].Pattern is not ast.LiteralPattern. got=%T", exp.Arms[0].Pattern)
+	}
+
+	array, ok := exp.Arms[1].Pattern.(*ast.ArrayPattern)
+
+	if !ok {
+		t.Fatalf("Arms[1].Pattern is not ast.ArrayPattern. got=%T", exp.Arms[1].Pattern)
+	}
+
+	if len(array.Elements) != 1 || array.Rest == nil || array.Rest.Value != "rest" {
+		t.Errorf("array pattern is wrong. got=%s", array.String())
+	}
+
+	if !testInfixExpression(t, exp.Arms[1].Guard, "a", ">", 0) {
+		return
+	}
+
+	if _, ok := exp.Arms[2].Pattern.(*ast.HashPattern); !ok {
+		t.Errorf("Arms[2].Pattern is not ast.HashPattern. got=%T", exp.Arms[2].Pattern)
+	}
+
+	if _, ok := exp.Arms[3].Pattern.(*ast.WildcardPattern); !ok {
+		t.Errorf("Arms[3].Pattern is not ast.WildcardPattern. got=%T", exp.Arms[3].Pattern)
+	}
+
+	expected := `match (x) {1 => one, [a, ...rest] if (a > 0) => a, {k: _} => 2, _ => 0}`
+
+	if exp.String() != expected {
+		t.Errorf("exp.String() wrong. expected=%q, got=%q", expected, exp.String())
+	}
+}
+
+/**
+ * 名前: TestConstStatements
+ * 概要: const文の解析テストを実装する
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestConstStatements(t *testing.T) {
+
+	l := lexer.New("const x = 5;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+
+	if !ok {
+		t.Fatalf("stmt is not ast.LetStatement. got=%T", program.Statements[0])
+	}
+
+	if !stmt.IsConst() {
+		t.Errorf("stmt.IsConst() is false")
+	}
+
+	if stmt.String() != "const x = 5;" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
+/**
+ * 名前: TestConstReassignmentErrors
+ * 概要: constへの再代入・再宣言が構文解析の時点でエラーになることをテストする
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestConstReassignmentErrors(t *testing.T) {
+
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"const x = 1; x = 2;", "cannot assign to constant x"},
+		{"const x = 1; let x = 2;", "cannot redeclare constant x"},
+		{"const x = 1; const x = 2;", "cannot redeclare constant x"},
+		{"const x = 1; let f = fn() { x = 2; };", "cannot assign to constant x"},
+		{"const x = 1; if (true) { x = 2; }", "cannot assign to constant x"},
+		{"1 = 2;", "invalid assignment target: 1"},
+		{"const x = 1; let f = fn(x) { x = 2; };", ""},
+		{"const x = 1; if (true) { let x = 2; x = 3; }", ""},
+		{"const x = 1; match (2) { x => x = 3 };", ""},
+		{"const c = 1; [c = 2 for c in [1]];", ""},
+		{"const c = 1; {c: c = 2 for c in [1]};", ""},
+		{"const c = 1; [[c = 2] for c in [1]];", ""},
+		{"const c = 1; [c = 2 for x in [1]];", "cannot assign to constant c"},
+		{"const c = 1; [c = 2, 3];", "cannot assign to constant c"},
+		{"const c = 1; {\"a\": c = 2};", "cannot assign to constant c"},
+		{"const c = 1; [fn() { const d = 1; d = 2 } for d in [1]];", "cannot assign to constant d"},
+		{"const c = 1; [[c = 2] for x in [1]];", "cannot assign to constant c"},
+		{"const c = 1; [[c = 2 for c in [1]] for x in [1]];", ""},
+		{"let x = 1; x = 2;", ""},
+		{"y = 2;", ""},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if tt.expectedError == "" {
+			if len(errors) != 0 {
+				t.Errorf("unexpected parser errors for %q: %v", tt.input, errors)
+			}
+			continue
+		}
+
+		if len(errors) == 0 || errors[0] != tt.expectedError {
+			t.Errorf("wrong parser errors for %q. expected=%q, got=%v", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+/**
+ * 名前: TestArrowFunctionParsing
+ * 概要: アロー関数の解析テストを実装する
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestArrowFunctionParsing(t *testing.T) {
+
+	tests := []struct {
+		input          string
+		expectedParams []string
+		expectedBody   string
+	}{
+		{"x => x * 2", []string{"x"}, "(x * 2)"},
+		{"(a, b) => a + b", []string{"a", "b"}, "(a + b)"},
+		{"() => 1", []string{}, "1"},
+		{"(a) => { let b = a; b }", []string{"a"}, "let b = a;b"},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T", stmt.Expression)
+		}
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Fatalf("function literal parameters wrong. want %d, got=%d", len(tt.expectedParams), len(function.Parameters))
+		}
+
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
+		}
+
+		if function.Body.String() != tt.expectedBody {
+			t.Errorf("function.Body.String() wrong. expected=%q, got=%q", tt.expectedBody, function.Body.String())
+		}
+	}
+}
+
+/**
+ * 名前: TestArrowFunctionInMatchGuard
+ * 概要: match式のガード条件の後ろの => がアロー関数にならないことをテストする
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestArrowFunctionInMatchGuard(t *testing.T) {
+
+	input := `match (x) { n if ok => n, n if (ok) => n, n if any(xs, y => y) => y => n }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.MatchExpression)
+
+	expected := []string{"ok", "ok", "any(xs, (y) => y)"}
+
+	for i, guard := range expected {
+		if exp.Arms[i].Guard.String() != guard {
+			t.Errorf("Arms[%d].Guard wrong. expected=%q, got=%q", i, guard, exp.Arms[i].Guard.String())
+		}
+	}
+
+	if _, ok := exp.Arms[2].Body.(*ast.FunctionLiteral); !ok {
+		t.Errorf("Arms[2].Body is not ast.FunctionLiteral. got=%T", exp.Arms[2].Body)
+	}
+}
+
+/**
+ * 名前: TestParsingComprehensions
+ * 概要: 配列とハッシュの内包表記の解析テストを実装する
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestParsingComprehensions(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[x * 2 for x in xs]", "[(x * 2) for x in xs]"},
+		{"[x for x in xs if x > 1]", "[x for x in xs if (x > 1)]"},
+		{"[x for x in xs if x > 1 if x < 5]", "[x for x in xs if (x > 1) if (x < 5)]"},
+		{"[[x, y] for x in xs for y in ys if x != y]", "[[x, y] for x in xs for y in ys if (x != y)]"},
+		{"[i + v for i, v in xs]", "[(i + v) for i, v in xs]"},
+		{"{k: v * 2 for k, v in h}", "{k:(v * 2) for k, v in h}"},
+		{"{x: true for x in xs if x}", "{x:true for x in xs if x}"},
+		{"[f(x) for x in g(xs) |> h()]", "[f(x) for x in (g(xs) |> h())]"},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	l := lexer.New("[x for x in xs]")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	comprehension, ok := stmt.Expression.(*ast.ListComprehension)
+
+	if !ok {
+		t.Fatalf("exp is not ast.ListComprehension. got=%T", stmt.Expression)
+	}
+
+	if len(comprehension.Clauses) != 1 || !testIdentifier(t, comprehension.Clauses[0].Iterable, "xs") {
+		t.Errorf("comprehension clauses wrong. got=%s", comprehension.String())
+	}
+}
+
+/**
+ * 名前: TestImportAndExportStatements
+ * 概要: import文とexport文の解析テストを実装する
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestImportAndExportStatements(t *testing.T) {
+
+	tests := []struct {
+		input        string
+		expectedPath string
+		expectedName string
+	}{
+		{`import "lib/math.monkey" as m;`, "lib/math.monkey", "m"},
+		{`import "./lib/strings.monkey"`, "./lib/strings.monkey", "strings"},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ImportStatement)
+
+		if !ok {
+			t.Fatalf("stmt is not ast.ImportStatement. got=%T", program.Statements[0])
+		}
+
+		if stmt.Path != tt.expectedPath {
+			t.Errorf("stmt.Path wrong. expected=%q, got=%q", tt.expectedPath, stmt.Path)
+		}
+
+		if stmt.Name.Value != tt.expectedName {
+			t.Errorf("stmt.Name wrong. expected=%q, got=%q", tt.expectedName, stmt.Name.Value)
+		}
+	}
+
+	l := lexer.New("export const x = 1;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExportStatement)
+
+	if !ok {
+		t.Fatalf("stmt is not ast.ExportStatement. got=%T", program.Statements[0])
+	}
+
+	if stmt.String() != "export const x = 1;" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
+/**
+ * 名前: TestImportAndExportErrors
+ * 概要: import文とexport文の構文エラーのテストを実装する
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestImportAndExportErrors(t *testing.T) {
+
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`import "my-lib.monkey"`, `cannot derive module name from "my-lib.monkey", use ` + "`as`"},
+		{`import lib`, "expected next token to be STRING, got IDENT instead"},
+		{`export 5;`, "expected declaration after export, got INT instead"},
+		{`if (true) { export let x = 1; }`, "export is only allowed at the top level"},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expectedError {
+			t.Errorf("wrong parser errors for %q. expected=%q, got=%v", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+/**
+ * 名前: TestTryExpressionParsing
+ * 概要: try式とthrow文の解析テストを実装する
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestTryExpressionParsing(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { f() } catch (e) { e.message }`, "try f() catch (e) (e.message)"},
+		{`try { f() } catch { 1 }`, "try f() catch 1"},
+		{`try { f() } finally { g() }`, "try f() finally g()"},
+		{`try { f() } catch (e) { 1 } finally { g() }`, "try f() catch (e) 1 finally g()"},
+		{`throw "boom";`, `throw boom;`},
+		{`fn() { return 1 }`, "fn() return 1;"},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	l := lexer.New(`try { f() }`)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+
+	if len(errors) == 0 || errors[0] != "expected catch or finally after try block" {
+		t.Errorf("wrong parser errors. got=%v", errors)
+	}
+}
+
+/**
+ * 名前: TestDeferStatementParsing
+ * 概要: defer文の解析テストを実装する
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestDeferStatementParsing(t *testing.T) {
+
+	l := lexer.New(`defer close(f, 1);`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.DeferStatement)
+
+	if !ok {
+		t.Fatalf("stmt is not ast.DeferStatement. got=%T", program.Statements[0])
+	}
+
+	if stmt.String() != "defer close(f, 1);" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+
+	l = lexer.New(`defer x + 1;`)
+	p = New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+
+	if len(errors) == 0 || errors[0] != "defer expects a function call, got (x + 1)" {
+		t.Errorf("wrong parser errors. got=%v", errors)
+	}
+}
+
+/**
+ * 名前: TestStructStatementParsing
+ * 概要: 構造体の定義の解析テストを実装する
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestStructStatementParsing(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`struct Point { x, y }`, "struct Point { x, y }"},
+		{"struct Point {\n x,\n y,\n fn norm() { self.x + self.y }\n fn add(o, k) { o }\n};", "struct Point { x, y; fn norm() ((self.x) + (self.y)); fn add(o, k) o }"},
+		{`export struct Empty {}`, "export struct Empty {}"},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`struct Point { x, x }`, "duplicate member x in struct Point"},
+		{`struct Point { x fn x() { 1 } }`, "duplicate member x in struct Point"},
+		{`struct Point { 1 }`, "expected field or method in struct Point, got INT instead"},
+		{`struct Point { x`, "expected field or method in struct Point, got EOF instead"},
+	}
+
+	for _, tt := range errorTests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expectedError {
+			t.Errorf("wrong parser errors for %q. expected=%q, got=%v", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+/**
+ * 名前: TestEnumParsing
+ * 概要: 列挙型の定義と列挙型のパターンの解析テストを実装する
+ * 引数:
+ * .. t *testing.T
+ * 戻り値:
+ *
+ */
+func TestEnumParsing(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`enum Status { Pending, Done(result), Failed(code, reason), }`, "enum Status { Pending, Done(result), Failed(code, reason) }"},
+		{`export enum Empty {};`, "export enum Empty {}"},
+		{`match (s) { Status.Done(r) => r, Status.Pending => 0 }`, "match (s) {Status.Done(r) => r, Status.Pending => 0}"},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`enum Status { A, A }`, "duplicate variant A in enum Status"},
+		{`enum Status { A() }`, "variant Status.A has no fields, omit the parentheses"},
+		{`enum Status { A B }`, "expected next token to be ,, got IDENT instead"},
+	}
+
+	for _, tt := range errorTests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expectedError {
+			t.Errorf("wrong parser errors for %q. expected=%q, got=%v", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+func TestTypeAnnotationParsing(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let x: int = 1;`, "let x: int = 1;"},
+		{`let xs: array[int] = [];`, "let xs: array[int] = [];"},
+		{`fn(a: int, b: array[int]) -> hash[string, int] { a }`, "fn(a: int, b: array[int]) -> hash[string, int] a"},
+		{`let apply = fn(f: fn(int) -> int, x) { f(x) };`, "let apply = fn(f: fn(int) -> int, x) f(x);"},
+		{`let inc = (n: int) => n + 1;`, "let inc = (n: int) => (n + 1);"},
+		{`struct Point { x fn norm() -> int { self.x } }`, "struct Point { x; fn norm() -> int (self.x) }"},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`let x: 1 = 1;`, "expected type, got INT instead"},
+		{`fn(a: int) -> { a }`, "expected type, got { instead"},
+		{`enum E { A(x: int) }`, "type annotations are not allowed on enum fields: E.A"},
+	}
+
+	for _, tt := range errorTests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expectedError {
+			t.Errorf("wrong parser errors for %q. expected=%q, got=%v", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+func TestSetLiteralAndInParsing(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`#{1, 2 + 3, "a"}`, `#{1, (2 + 3), a}`},
+		{`#{}`, `#{}`},
+		{`x in #{1}`, `(x in #{1})`},
+		{`1 + 2 in xs == true`, `(((1 + 2) in xs) == true)`},
+		{`[x for x in xs if x in ys]`, `[x for x in xs if (x in ys)]`},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestTupleParsing(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(1, 2)`, `(1, 2)`},
+		{`(1, 2,)`, `(1, 2)`},
+		{`(1,)`, `(1,)`},
+		{`()`, `()`},
+		{`(1)`, `1`},
+		{`(1 + 2, f(x), (a, b))`, `((1 + 2), f(x), (a, b))`},
+		{`let f = (a, b) => a;`, `let f = (a, b) => a;`},
+		{`match (p) { (0, y) => y, (x,) => x, (x) => x }`, `match (p) {(0, y) => y, (x,) => x, x => x}`},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestBytesLiteralParsing(t *testing.T) {
+
+	tests := []struct {
+		input    string
+		expected []byte
+	}{
+		{`b"hello"`, []byte("hello")},
+		{`b""`, []byte{}},
+		{`b"\x00\xff\x7F"`, []byte{0, 0xff, 0x7f}},
+		{`b"a\nb\tc\rd\0"`, []byte("a\nb\tc\rd\x00")},
+		{`b"\\ \""`, []byte(`\ "`)},
+	}
+
+	for _, tt := range tests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+
+		literal, ok := stmt.Expression.(*ast.BytesLiteral)
+
+		if !ok {
+			t.Fatalf("exp is not ast.BytesLiteral. got=%T", stmt.Expression)
+		}
+
+		if string(literal.Value) != string(tt.expected) {
+			t.Errorf("literal.Value wrong. expected=%q, got=%q", tt.expected, literal.Value)
+		}
+
+		if literal.String() != tt.input {
+			t.Errorf("literal.String() wrong. expected=%q, got=%q", tt.input, literal.String())
+		}
+	}
+
+	errorTests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`b"\q"`, `invalid escape sequence in bytes literal: \q`},
+		{`b"\xZZ"`, `invalid escape sequence in bytes literal: \xZZ`},
+		{`b"\x1"`, `invalid escape sequence in bytes literal: \x1`},
+	}
+
+	for _, tt := range errorTests {
+
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expectedError {
+			t.Errorf("wrong parser errors for %q. expected=%q, got=%v", tt.input, tt.expectedError, errors)
+		}
+	}
+}
@@ -63,7 +63,7 @@ func Start(in io.Reader, out io.Writer) {
 		evaluated := evaluator.Eval(program, env)
 
 		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
+			io.WriteString(out, object.Repr(evaluated))
 			io.WriteString(out, "\n")
 		}
 	}
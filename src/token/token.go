@@ -6,6 +6,8 @@
  */
 package token
 
+import "fmt"
+
 // monkeylangで使用できるトークンの種類を定義する
 // .. トークンは、字句解析の結果として得られる
 const (
@@ -18,6 +20,7 @@ const (
 	// リテラル : 扱うデータの型
 	INT    = "INT"
 	STRING = "STRING"
+	BYTES  = "BYTES" // バイト列 : b"..."
 
 	// 演算子 : 使用できる演算子
 	ASSIGN   = "="
@@ -27,6 +30,12 @@ const (
 	ASTERISK = "*"
 	SLASH    = "/"
 
+	// NULL合体演算子 : 左辺がnullのときに右辺を評価する
+	NULLISH = "??"
+
+	// パイプライン演算子 : 左辺の値を右辺の関数の第1引数として渡す
+	PIPELINE = "|>"
+
 	// 比較演算子 : 使用できる比較演算子
 	EQ     = "=="
 	NOT_EQ = "!="
@@ -36,47 +45,115 @@ const (
 	// デリミタ(区切り文字) : コード上の区切り文字
 	COMMA     = ","
 	SEMICOLON = ";"
+	DOT       = "."
 
 	LPAREN = "("
 	RPAREN = ")"
 
-	LBRACE = "{"
-	RBRACE = "}"
+	LBRACE     = "{"
+	RBRACE     = "}"
+	SET_LBRACE = "#{" // セットリテラルの開始
 
 	LBRACKET = "["
 	RBRACKET = "]"
 
 	COLON = ":"
 
+	// オプショナルアクセス : 左辺がnullのときはnullを返す
+	OPTIONAL_DOT      = "?."
+	OPTIONAL_LBRACKET = "?["
+
+	ARROW    = "=>"  // match式の腕の区切り、アロー関数
+	RARROW   = "->"  // 関数の戻り値の型注釈
+	ELLIPSIS = "..." // 配列パターンの残り要素
+
 	// キーワード : コード上で使用する予約語
 	FUNCTION = "FUNCTION" // 関数定義
 	LET      = "LET"      // 変数定義
+	CONST    = "CONST"    // 定数定義
 	TRUE     = "TRUE"     // 真
 	FALSE    = "FALSE"    // 偽
+	NULL     = "NULL"     // null
 	IF       = "IF"       // 構文構造使用: 条件分岐
 	ELSE     = "ELSE"     // 構文構造使用: 条件分岐
 	RETURN   = "RETURN"   // 構文構造使用: 関数からの戻り値
+	MATCH    = "MATCH"    // 構文構造使用: パターンマッチ
+	FOR      = "FOR"      // 構文構造使用: 内包表記の反復
+	IN       = "IN"       // 構文構造使用: 内包表記の反復
+	IMPORT   = "IMPORT"   // モジュールの読み込み
+	EXPORT   = "EXPORT"   // モジュールから公開する宣言
+	AS       = "AS"       // 読み込んだモジュールの名前
+	THROW    = "THROW"    // 構文構造使用: 例外の送出
+	TRY      = "TRY"      // 構文構造使用: 例外の捕捉
+	CATCH    = "CATCH"    // 構文構造使用: 例外の捕捉
+	FINALLY  = "FINALLY"  // 構文構造使用: 例外の有無によらず実行する処理
+	DEFER    = "DEFER"    // 構文構造使用: 関数を抜けるときに実行する呼び出し
+	STRUCT   = "STRUCT"   // 構造体の定義
+	ENUM     = "ENUM"     // 列挙型の定義
 )
 
 type TokenType string
 
 // トークンを表す構造体
 type Token struct {
-	Type    TokenType // トークンの種類
-	Literal string    // トークン文字列（ 変数名 や + , - などの文字列 ）
+	Type     TokenType // トークンの種類
+	Literal  string    // トークン文字列（ 変数名 や + , - などの文字列 ）
+	Position           // ソースコード上のトークンの開始位置
+}
+
+// ソースコード上の位置を表す構造体
+// .. Line, Column は1から始まる。0 のときは位置が不明であることを表す
+type Position struct {
+	Line   int // 行番号
+	Column int // 列番号
+}
+
+/**
+ * 名前: Position.String
+ * 処理: 位置を "行:列" の形式の文字列にして返す
+ * 引数: なし
+ * 戻り値: string
+ */
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+/**
+ * 名前: Position.IsValid
+ * 処理: 位置が分かっているかどうかを判定する
+ * 引数: なし
+ * 戻り値: bool
+ */
+func (p Position) IsValid() bool {
+	return p.Line > 0
 }
 
 // 予約語のマップ
 // .. 予約語は、言語の構文構造に使用するキーワード
 // .. 予約語は、変数名や関数名として使用できない
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":      FUNCTION,
+	"let":     LET,
+	"const":   CONST,
+	"true":    TRUE,
+	"false":   FALSE,
+	"null":    NULL,
+	"if":      IF,
+	"else":    ELSE,
+	"return":  RETURN,
+	"match":   MATCH,
+	"for":     FOR,
+	"in":      IN,
+	"import":  IMPORT,
+	"export":  EXPORT,
+	"as":      AS,
+	"throw":   THROW,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
+	"defer":   DEFER,
+	"struct":  STRUCT,
+	"enum":    ENUM,
 }
 
 /**